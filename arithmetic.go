@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrNotFound is returned by Increment/Decrement when key is missing or
+// already expired.
+var ErrNotFound = errors.New("cache: key not found")
+
+// NotNumericError reports that Increment/Decrement was asked to mutate an
+// item whose stored value cannot be coerced to a number.
+type NotNumericError struct {
+	Key string
+}
+
+func (e *NotNumericError) Error() string {
+	return fmt.Sprintf("cache: value at %q is not numeric", e.Key)
+}
+
+func coerceInt(data interface{}) (int, bool) {
+	switch data.(type) {
+	case float32, float64:
+		return int(reflect.ValueOf(data).Float()), true
+	case int, int8, int16, int32, int64:
+		return int(reflect.ValueOf(data).Int()), true
+	case uint, uint8, uint16, uint32, uint64:
+		return int(reflect.ValueOf(data).Uint()), true
+	}
+	return 0, false
+}
+
+func coerceInt64(data interface{}) (int64, bool) {
+	switch data.(type) {
+	case float32, float64:
+		return int64(reflect.ValueOf(data).Float()), true
+	case int, int8, int16, int32, int64:
+		return reflect.ValueOf(data).Int(), true
+	case uint, uint8, uint16, uint32, uint64:
+		return int64(reflect.ValueOf(data).Uint()), true
+	}
+	return 0, false
+}
+
+func coerceUint64(data interface{}) (uint64, bool) {
+	switch data.(type) {
+	case float32, float64:
+		return uint64(reflect.ValueOf(data).Float()), true
+	case int, int8, int16, int32, int64:
+		return uint64(reflect.ValueOf(data).Int()), true
+	case uint, uint8, uint16, uint32, uint64:
+		return reflect.ValueOf(data).Uint(), true
+	}
+	return 0, false
+}
+
+func coerceFloat64(data interface{}) (float64, bool) {
+	switch data.(type) {
+	case float32, float64:
+		return reflect.ValueOf(data).Float(), true
+	case int, int8, int16, int32, int64:
+		return float64(reflect.ValueOf(data).Int()), true
+	case uint, uint8, uint16, uint32, uint64:
+		return float64(reflect.ValueOf(data).Uint()), true
+	}
+	return 0, false
+}
+
+// IncrementInt adds delta to item's value, coerced the same way Item.Int
+// is, and stores the result back as an int. It locks item for the whole
+// read-modify-write and never touches its TTL or expiration. key is used
+// only to build a *NotNumericError. It is the shared implementation every
+// adapter's IncrementInt/DecrementInt uses, the latter by negating delta.
+func IncrementInt(item *Item, key string, delta int) (int, error) {
+	item.Lock()
+	defer item.Unlock()
+
+	n, ok := coerceInt(item.data)
+	if !ok {
+		return 0, &NotNumericError{Key: key}
+	}
+	n += delta
+	item.data = n
+	return n, nil
+}
+
+// IncrementInt64 is IncrementInt for int64-valued items.
+func IncrementInt64(item *Item, key string, delta int64) (int64, error) {
+	item.Lock()
+	defer item.Unlock()
+
+	n, ok := coerceInt64(item.data)
+	if !ok {
+		return 0, &NotNumericError{Key: key}
+	}
+	n += delta
+	item.data = n
+	return n, nil
+}
+
+// IncrementUint64 is IncrementInt for uint64-valued items.
+func IncrementUint64(item *Item, key string, delta uint64) (uint64, error) {
+	item.Lock()
+	defer item.Unlock()
+
+	n, ok := coerceUint64(item.data)
+	if !ok {
+		return 0, &NotNumericError{Key: key}
+	}
+	n += delta
+	item.data = n
+	return n, nil
+}
+
+// DecrementUint64 subtracts delta from item's uint64 value, wrapping on
+// underflow the way any other uint64 subtraction does.
+func DecrementUint64(item *Item, key string, delta uint64) (uint64, error) {
+	item.Lock()
+	defer item.Unlock()
+
+	n, ok := coerceUint64(item.data)
+	if !ok {
+		return 0, &NotNumericError{Key: key}
+	}
+	n -= delta
+	item.data = n
+	return n, nil
+}
+
+// IncrementFloat64 is IncrementInt for float64-valued items.
+func IncrementFloat64(item *Item, key string, delta float64) (float64, error) {
+	item.Lock()
+	defer item.Unlock()
+
+	n, ok := coerceFloat64(item.data)
+	if !ok {
+		return 0, &NotNumericError{Key: key}
+	}
+	n += delta
+	item.data = n
+	return n, nil
+}
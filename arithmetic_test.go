@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestIncrementIntConcurrent increments a single shared Item from many
+// goroutines at once and checks the final value is exactly the sum of
+// every increment, proving IncrementInt's item.Lock()-held
+// read-modify-write doesn't lose updates the way an unlocked read+write
+// would.
+func TestIncrementIntConcurrent(t *testing.T) {
+	item := NewItem(0, NoExpiration)
+
+	const goroutines = 50
+	const perGoroutine = 200
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				if _, err := IncrementInt(item, "counter", 1); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	n, err := item.Int()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := goroutines * perGoroutine; n != want {
+		t.Fatalf("got %d, want %d", n, want)
+	}
+}
+
+// TestIncrementIntNotNumeric checks that incrementing an item whose value
+// isn't numeric fails with *NotNumericError instead of silently coercing
+// or panicking.
+func TestIncrementIntNotNumeric(t *testing.T) {
+	item := NewItem("not a number", NoExpiration)
+
+	_, err := IncrementInt(item, "some-key", 1)
+	var notNumeric *NotNumericError
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+	if ne, ok := err.(*NotNumericError); !ok {
+		t.Fatalf("got error of type %T, want %T", err, notNumeric)
+	} else if ne.Key != "some-key" {
+		t.Fatalf("got Key %q, want %q", ne.Key, "some-key")
+	}
+}
+
+// TestIncrementFloat64Concurrent is TestIncrementIntConcurrent for the
+// float64 variant, since it goes through a separate coercion path.
+func TestIncrementFloat64Concurrent(t *testing.T) {
+	item := NewItem(float64(0), NoExpiration)
+
+	const goroutines = 50
+	const perGoroutine = 200
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				if _, err := IncrementFloat64(item, "counter", 0.5); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	f, err := item.Float64()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := float64(goroutines*perGoroutine) * 0.5; f != want {
+		t.Fatalf("got %v, want %v", f, want)
+	}
+}
@@ -1,132 +1,186 @@
-// REF: https://github.com/wunderlist/ttlcache
-
-package cache
-
-import (
-	"sync"
-	"time"
-)
-
-// Cache is a synchronised map of items that auto-expire once stale
-type Cache struct {
-	mutex           sync.RWMutex
-	ttl             time.Duration
-	cleanupInterval time.Duration
-	items           map[string]*Item
-}
-
-// Set is a thread-safe way to add new items to the map
-func (cache *Cache) Set(key string, data interface{}) {
-	cache.mutex.Lock()
-	item := &Item{data: data}
-	item.touch(cache.ttl)
-	cache.items[key] = item
-	cache.mutex.Unlock()
-}
-
-// SetIfNotExist is a thread-safe way to add new items to the map.
-// Add successfully only when item is not exists.
-func (cache *Cache) SetIfNotExist(key string, data interface{}) (ok bool) {
-	cache.mutex.Lock()
-
-	item, exists := cache.items[key]
-	if exists && !item.expired() {
-		cache.mutex.Unlock()
-		return false
-	}
-
-	item = &Item{data: data}
-	item.touch(cache.ttl)
-	cache.items[key] = item
-
-	cache.mutex.Unlock()
-
-	return true
-}
-
-// Get is a thread-safe way to lookup items
-// Every lookup, also touches the item, hence extending it's life
-func (cache *Cache) Get(key string) (item *Item, found bool) {
-	cache.mutex.Lock()
-	item, exists := cache.items[key]
-	if !exists || item.expired() {
-		item = nil
-		found = false
-	} else {
-		item.touch(cache.ttl)
-		found = true
-	}
-	cache.mutex.Unlock()
-	return
-}
-
-// Has is a thread-safe way to check if item exists.
-func (cache *Cache) Has(key string) (found bool) {
-	cache.mutex.Lock()
-	item, exists := cache.items[key]
-	if !exists || item.expired() {
-		found = false
-	} else {
-		found = true
-	}
-	cache.mutex.Unlock()
-	return
-}
-
-// Count returns the number of items in the cache
-// (helpful for tracking memory leaks)
-func (cache *Cache) Count() int {
-	cache.mutex.RLock()
-	count := len(cache.items)
-	cache.mutex.RUnlock()
-	return count
-}
-
-func (cache *Cache) cleanup() {
-	cache.mutex.Lock()
-	for key, item := range cache.items {
-		if item.expired() {
-			delete(cache.items, key)
-		}
-	}
-	cache.mutex.Unlock()
-}
-
-func (cache *Cache) startCleanupTimer() {
-	duration := cache.cleanupInterval
-	if duration < time.Second {
-		duration = time.Second
-	}
-	ticker := time.Tick(duration)
-	go (func() {
-		for {
-			select {
-			case <-ticker:
-				cache.cleanup()
-			}
-		}
-	})()
-}
-
-// NewCache is a helper to create instance of the Cache struct
-func NewCache(expire time.Duration) *Cache {
-	cache := &Cache{
-		ttl:             expire,
-		cleanupInterval: expire,
-		items:           map[string]*Item{},
-	}
-	cache.startCleanupTimer()
-	return cache
-}
-
-// NewCacheEx is a helper to create instance of the Cache struct
-// with specified cleanup interval
-func NewCacheEx(expire, cleanupInterval time.Duration) *Cache {
-	cache := &Cache{
-		ttl:             expire,
-		cleanupInterval: cleanupInterval,
-		items:           map[string]*Item{},
-	}
-	cache.startCleanupTimer()
-	return cache
-}
+// REF: https://github.com/wunderlist/ttlcache
+// REF: https://github.com/astaxie/beego (adapter registry pattern)
+
+package cache
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Sentinel TTLs accepted by SetWithTTL and SetIfNotExistWithTTL, matching
+// the semantics of patrickmn/go-cache.
+const (
+	// NoExpiration marks an item as never expiring.
+	NoExpiration time.Duration = -1
+	// DefaultExpiration defers to the Cache's own configured default TTL.
+	DefaultExpiration time.Duration = 0
+)
+
+// Cache is the behaviour shared by every cache backend: an in-process map
+// (see the memory subpackage), a directory of files (file), or a shared
+// Redis instance (redis). Application code should depend on this interface
+// rather than a concrete adapter so the backend can be swapped via NewCache
+// without touching call sites.
+type Cache interface {
+	// Get looks up key. It also touches the item, extending its life.
+	Get(key string) (item *Item, found bool)
+
+	// Set is a thread-safe way to add or overwrite an item, using the
+	// cache's default TTL.
+	Set(key string, data interface{})
+
+	// SetWithTTL is like Set but expires the item after ttl instead of the
+	// cache's default. Pass NoExpiration for an item that never expires, or
+	// DefaultExpiration to fall back to the cache's default TTL.
+	SetWithTTL(key string, data interface{}, ttl time.Duration)
+
+	// SetWithExpiration is like Set but expires the item at an absolute
+	// point in time instead of after a TTL. Unlike Set/SetWithTTL, looking
+	// the item up does not extend its life.
+	SetWithExpiration(key string, data interface{}, at time.Time)
+
+	// SetIfNotExist adds data under key only if it does not already exist
+	// (or has expired), using the cache's default TTL. It returns false
+	// otherwise.
+	SetIfNotExist(key string, data interface{}) bool
+
+	// SetIfNotExistWithTTL is like SetIfNotExist but expires the item
+	// after ttl instead of the cache's default.
+	SetIfNotExistWithTTL(key string, data interface{}, ttl time.Duration) bool
+
+	// Has reports whether a live, non-expired item exists under key.
+	Has(key string) bool
+
+	// Delete removes key, returning its value if it was present. If an
+	// OnEvicted callback is set, it is invoked with the removed value.
+	Delete(key string) (prev interface{}, existed bool)
+
+	// OnEvicted sets a callback invoked whenever an item leaves the cache
+	// on its own (TTL expiry) or via Delete, with the evicted key and
+	// value. It is called outside of any internal lock, so the callback
+	// may safely call back into the cache. Passing nil disables it.
+	OnEvicted(f func(key string, value interface{}))
+
+	// Count returns the number of items the cache holds. Expired items that
+	// have not been removed yet are counted too; the redis adapter counts
+	// every key in its selected database.
+	Count() int
+
+	// Clear removes every item from the cache.
+	Clear()
+
+	// Save writes every live item to w as a gob-encoded snapshot. Callers
+	// must RegisterType any concrete type they store before decoding a
+	// snapshot containing it, the same way encoding/gob requires for any
+	// interface value.
+	Save(w io.Writer) error
+
+	// SaveFile is like Save but writes to the file at path, creating or
+	// truncating it.
+	SaveFile(path string) error
+
+	// Load merges a snapshot written by Save into the cache, skipping
+	// entries that have already expired.
+	Load(r io.Reader) error
+
+	// LoadFile is like Load but reads from the file at path.
+	LoadFile(path string) error
+
+	// GetOrLoad returns the cached item under key, or invokes loader and
+	// caches its result if there isn't one. Concurrent misses for the same
+	// key collapse into a single loader call. If loader returns ErrGone (or
+	// an error wrapped with Tombstone), a tombstone is cached instead, so
+	// subsequent calls return ErrGone without invoking loader again.
+	GetOrLoad(key string, loader func() (interface{}, error)) (*Item, error)
+
+	// Add adds data under key only if it does not already exist (or has
+	// expired), using the cache's default TTL. It is an alias for
+	// SetIfNotExist, provided for callers who find that name clearer.
+	Add(key string, data interface{}) bool
+
+	// Replace overwrites key's data only if it already exists (and hasn't
+	// expired), leaving its TTL/expiration untouched - unlike Set, which
+	// starts a fresh item under the cache's default TTL. It returns false
+	// if key was not present.
+	Replace(key string, data interface{}) bool
+
+	// IncrementInt adds delta to the numeric value stored under key,
+	// coerced the same way Item.Int is, and returns the new value. The
+	// item's TTL and expiration are left untouched, matching
+	// patrickmn/go-cache's behaviour, which rate limiters built on top of
+	// the cache rely on. It returns ErrNotFound if key is missing or
+	// expired, or a *NotNumericError if its value cannot be coerced to a
+	// number.
+	IncrementInt(key string, delta int) (int, error)
+
+	// DecrementInt is IncrementInt with delta negated.
+	DecrementInt(key string, delta int) (int, error)
+
+	// IncrementInt64 is IncrementInt for int64-valued items.
+	IncrementInt64(key string, delta int64) (int64, error)
+
+	// DecrementInt64 is IncrementInt64 with delta negated.
+	DecrementInt64(key string, delta int64) (int64, error)
+
+	// IncrementUint64 is IncrementInt for uint64-valued items.
+	IncrementUint64(key string, delta uint64) (uint64, error)
+
+	// DecrementUint64 subtracts delta from the uint64 value stored under
+	// key, wrapping on underflow the way any other uint64 subtraction does.
+	DecrementUint64(key string, delta uint64) (uint64, error)
+
+	// IncrementFloat64 is IncrementInt for float64-valued items.
+	IncrementFloat64(key string, delta float64) (float64, error)
+
+	// DecrementFloat64 is IncrementFloat64 with delta negated.
+	DecrementFloat64(key string, delta float64) (float64, error)
+}
+
+// Adapter opens a new, independent Cache instance for a registered backend.
+// Adapters register themselves from an init function, following the pattern
+// used by database/sql drivers.
+type Adapter interface {
+	Open(configJSON string) (Cache, error)
+}
+
+var (
+	adaptersMu sync.RWMutex
+	adapters   = make(map[string]Adapter)
+)
+
+// Register makes a Cache adapter available under name. It is meant to be
+// called from an adapter package's init function, e.g.:
+//
+//	import _ "github.com/go-apibox/cache/redis"
+//
+// Register panics if adapter is nil or name is already registered.
+func Register(name string, adapter Adapter) {
+	adaptersMu.Lock()
+	defer adaptersMu.Unlock()
+
+	if adapter == nil {
+		panic("cache: Register adapter is nil")
+	}
+	if _, dup := adapters[name]; dup {
+		panic("cache: Register called twice for adapter " + name)
+	}
+	adapters[name] = adapter
+}
+
+// NewCache opens a Cache using the adapter registered under adapterName,
+// configured via configJSON (adapter-specific, e.g. `{"conn":"127.0.0.1:6379"}`
+// for the redis adapter). The adapter's package must be imported (a blank
+// import is enough) so its init function has registered it.
+func NewCache(adapterName, configJSON string) (Cache, error) {
+	adaptersMu.RLock()
+	adapter, ok := adapters[adapterName]
+	adaptersMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("cache: unknown adapter %q (forgotten import?)", adapterName)
+	}
+	return adapter.Open(configJSON)
+}
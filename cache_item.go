@@ -6,91 +6,135 @@ package cache
 import (
 	"errors"
 	"log"
-	"reflect"
 	"sync"
 	"time"
 )
 
-// Item represents a record in the cache map
+// Item represents a record in the cache map. Each item carries its own TTL
+// so a cache can mix items with different lifetimes (see Cache.SetWithTTL).
 type Item struct {
 	sync.RWMutex
 	data    interface{}
+	ttl     time.Duration
+	fixed   bool // expires was set explicitly (SetWithExpiration); Touch is a no-op
 	expires *time.Time
 }
 
-func (item *Item) touch(duration time.Duration) {
+// NewItem builds an Item holding data, with its own ttl. A ttl of
+// NoExpiration means the item never expires; DefaultExpiration is not
+// meaningful here and must already have been resolved to a concrete
+// duration by the caller.
+func NewItem(data interface{}, ttl time.Duration) *Item {
+	item := &Item{data: data, ttl: ttl}
+	item.Touch()
+	return item
+}
+
+// NewItemWithExpiration builds an Item holding data with an explicit,
+// fixed absolute expiration time, e.g. one read back from a persisted
+// backend, or one set via Cache.SetWithExpiration. Touch is a no-op on
+// such items: looking them up does not extend their life.
+func NewItemWithExpiration(data interface{}, expires time.Time) *Item {
+	return &Item{data: data, fixed: true, expires: &expires}
+}
+
+// Touch refreshes the item's expiration using its own ttl. It does nothing
+// for items with a fixed (explicitly set) expiration or with NoExpiration.
+func (item *Item) Touch() {
 	item.Lock()
-	expiration := time.Now().Add(duration)
+	defer item.Unlock()
+	if item.fixed || item.ttl == NoExpiration {
+		return
+	}
+	expiration := time.Now().Add(item.ttl)
 	item.expires = &expiration
+}
+
+// Expired reports whether the item's expiration time has passed. An item
+// with no expiration (expires == nil) is never expired.
+func (item *Item) Expired() bool {
+	item.RLock()
+	defer item.RUnlock()
+	if item.expires == nil {
+		return false
+	}
+	return item.expires.Before(time.Now())
+}
+
+// Data returns the item's raw, untyped value.
+func (item *Item) Data() interface{} {
+	item.RLock()
+	defer item.RUnlock()
+	return item.data
+}
+
+// Replace overwrites the item's value in place, leaving its TTL and
+// expiration untouched - unlike building a fresh Item via NewItem, which
+// starts a new expiration window. See Cache.Replace.
+func (item *Item) Replace(data interface{}) {
+	item.Lock()
+	item.data = data
 	item.Unlock()
 }
 
-func (item *Item) expired() bool {
-	var value bool
+// ExpiresAt returns the item's absolute expiration time.
+func (item *Item) ExpiresAt() time.Time {
 	item.RLock()
+	defer item.RUnlock()
 	if item.expires == nil {
-		value = true
-	} else {
-		value = item.expires.Before(time.Now())
+		return time.Time{}
 	}
-	item.RUnlock()
-	return value
+	return *item.expires
 }
 
 // Float64 coerces into a float64
 func (i *Item) Float64() (float64, error) {
-	switch i.data.(type) {
-	case float32, float64:
-		return reflect.ValueOf(i.data).Float(), nil
-	case int, int8, int16, int32, int64:
-		return float64(reflect.ValueOf(i.data).Int()), nil
-	case uint, uint8, uint16, uint32, uint64:
-		return float64(reflect.ValueOf(i.data).Uint()), nil
+	i.RLock()
+	defer i.RUnlock()
+	f, ok := coerceFloat64(i.data)
+	if !ok {
+		return 0, errors.New("invalid value type")
 	}
-	return 0, errors.New("invalid value type")
+	return f, nil
 }
 
 // Int coerces into an int
 func (i *Item) Int() (int, error) {
-	switch i.data.(type) {
-	case float32, float64:
-		return int(reflect.ValueOf(i.data).Float()), nil
-	case int, int8, int16, int32, int64:
-		return int(reflect.ValueOf(i.data).Int()), nil
-	case uint, uint8, uint16, uint32, uint64:
-		return int(reflect.ValueOf(i.data).Uint()), nil
+	i.RLock()
+	defer i.RUnlock()
+	n, ok := coerceInt(i.data)
+	if !ok {
+		return 0, errors.New("invalid value type")
 	}
-	return 0, errors.New("invalid value type")
+	return n, nil
 }
 
 // Int64 coerces into an int64
 func (i *Item) Int64() (int64, error) {
-	switch i.data.(type) {
-	case float32, float64:
-		return int64(reflect.ValueOf(i.data).Float()), nil
-	case int, int8, int16, int32, int64:
-		return reflect.ValueOf(i.data).Int(), nil
-	case uint, uint8, uint16, uint32, uint64:
-		return int64(reflect.ValueOf(i.data).Uint()), nil
+	i.RLock()
+	defer i.RUnlock()
+	n, ok := coerceInt64(i.data)
+	if !ok {
+		return 0, errors.New("invalid value type")
 	}
-	return 0, errors.New("invalid value type")
+	return n, nil
 }
 
 // Uint64 coerces into an uint64
 func (i *Item) Uint64() (uint64, error) {
-	switch i.data.(type) {
-	case float32, float64:
-		return uint64(reflect.ValueOf(i.data).Float()), nil
-	case int, int8, int16, int32, int64:
-		return uint64(reflect.ValueOf(i.data).Int()), nil
-	case uint, uint8, uint16, uint32, uint64:
-		return reflect.ValueOf(i.data).Uint(), nil
+	i.RLock()
+	defer i.RUnlock()
+	n, ok := coerceUint64(i.data)
+	if !ok {
+		return 0, errors.New("invalid value type")
 	}
-	return 0, errors.New("invalid value type")
+	return n, nil
 }
 
 // Map type asserts to `map`
 func (i *Item) Map() (map[string]interface{}, error) {
+	i.RLock()
+	defer i.RUnlock()
 	if m, ok := (i.data).(map[string]interface{}); ok {
 		return m, nil
 	}
@@ -99,6 +143,8 @@ func (i *Item) Map() (map[string]interface{}, error) {
 
 // Array type asserts to an `array`
 func (i *Item) Array() ([]interface{}, error) {
+	i.RLock()
+	defer i.RUnlock()
 	if a, ok := (i.data).([]interface{}); ok {
 		return a, nil
 	}
@@ -107,6 +153,8 @@ func (i *Item) Array() ([]interface{}, error) {
 
 // Bool type asserts to `bool`
 func (i *Item) Bool() (bool, error) {
+	i.RLock()
+	defer i.RUnlock()
 	if s, ok := (i.data).(bool); ok {
 		return s, nil
 	}
@@ -115,6 +163,8 @@ func (i *Item) Bool() (bool, error) {
 
 // String type asserts to `string`
 func (i *Item) String() (string, error) {
+	i.RLock()
+	defer i.RUnlock()
 	if s, ok := (i.data).(string); ok {
 		return s, nil
 	}
@@ -123,6 +173,8 @@ func (i *Item) String() (string, error) {
 
 // Bytes type asserts to `[]byte`
 func (i *Item) Bytes() ([]byte, error) {
+	i.RLock()
+	defer i.RUnlock()
 	if s, ok := (i.data).([]byte); ok {
 		return []byte(s), nil
 	}
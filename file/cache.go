@@ -0,0 +1,484 @@
+// Package file implements the "file" cache.Cache adapter: each item is
+// gob-encoded to its own file under a directory, so the cache can be shared
+// between processes or survive a restart without holding everything in
+// memory. Because an Item's data is interface{}, callers must
+// cache.RegisterType the concrete types they store before using this
+// adapter, the same way encoding/gob requires for any interface value.
+package file
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/go-apibox/cache"
+)
+
+// record is the on-disk representation of a single cached item. TTL and
+// Fixed mirror cache.Item's own bookkeeping so a Get can decide whether to
+// extend the item's life the same way the memory adapter does.
+type record struct {
+	Data    interface{}
+	TTL     time.Duration
+	Fixed   bool
+	Expires time.Time // zero means "never expires"
+}
+
+func recordExpired(rec record) bool {
+	return !rec.Expires.IsZero() && rec.Expires.Before(time.Now())
+}
+
+// Cache is a cache.Cache backed by one gob-encoded file per key.
+type Cache struct {
+	mutex sync.Mutex
+	dir   string
+	ttl   time.Duration
+
+	onEvictedMu sync.RWMutex
+	onEvicted   func(key string, value interface{})
+	loadGroup   singleflight.Group
+}
+
+var _ cache.Cache = (*Cache)(nil)
+
+// New creates a Cache that stores its items under dir, creating it if
+// necessary, with items expiring ttl after they are last touched.
+func New(dir string, ttl time.Duration) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Cache{dir: dir, ttl: ttl}, nil
+}
+
+// path returns the on-disk path for key. The key is hex-encoded so arbitrary
+// strings are safe to use as filenames.
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, hex.EncodeToString([]byte(key))+".gob")
+}
+
+// writeRecordLocked is writeRecord for a caller already holding c.mutex.
+func (c *Cache) writeRecordLocked(key string, rec record) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(rec); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path(key), buf.Bytes(), 0o644)
+}
+
+func (c *Cache) writeRecord(key string, rec record) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.writeRecordLocked(key, rec)
+}
+
+// writeTTLLocked is writeTTL for a caller already holding c.mutex.
+func (c *Cache) writeTTLLocked(key string, data interface{}, ttl time.Duration) error {
+	rec := record{Data: data, TTL: ttl}
+	if ttl != cache.NoExpiration {
+		rec.Expires = time.Now().Add(ttl)
+	}
+	return c.writeRecordLocked(key, rec)
+}
+
+// writeTTL persists data expiring ttl from now (or never, for NoExpiration).
+func (c *Cache) writeTTL(key string, data interface{}, ttl time.Duration) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.writeTTLLocked(key, data, ttl)
+}
+
+// writeAt persists data with a fixed, absolute expiration.
+func (c *Cache) writeAt(key string, data interface{}, at time.Time) error {
+	return c.writeRecord(key, record{Data: data, Fixed: true, Expires: at})
+}
+
+// readLocked is read for a caller already holding c.mutex.
+func (c *Cache) readLocked(key string) (record, bool) {
+	b, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return record{}, false
+	}
+
+	var rec record
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&rec); err != nil {
+		return record{}, false
+	}
+	return rec, true
+}
+
+func (c *Cache) read(key string) (record, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.readLocked(key)
+}
+
+// removeLocked is remove for a caller already holding c.mutex.
+func (c *Cache) removeLocked(key string) bool {
+	return os.Remove(c.path(key)) == nil
+}
+
+func (c *Cache) remove(key string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.removeLocked(key)
+}
+
+// Set is a thread-safe way to add or overwrite an item, using the cache's
+// default TTL.
+func (c *Cache) Set(key string, data interface{}) {
+	c.SetWithTTL(key, data, cache.DefaultExpiration)
+}
+
+// SetWithTTL is like Set but expires the item after ttl instead of the
+// cache's default.
+func (c *Cache) SetWithTTL(key string, data interface{}, ttl time.Duration) {
+	if ttl == cache.DefaultExpiration {
+		ttl = c.ttl
+	}
+	c.writeTTL(key, data, ttl)
+}
+
+// SetWithExpiration is like Set but expires the item at an absolute point
+// in time instead of after a TTL.
+func (c *Cache) SetWithExpiration(key string, data interface{}, at time.Time) {
+	c.writeAt(key, data, at)
+}
+
+// SetIfNotExist adds data under key only if it does not already exist
+// (or has expired), using the cache's default TTL. It returns false
+// otherwise.
+func (c *Cache) SetIfNotExist(key string, data interface{}) bool {
+	return c.SetIfNotExistWithTTL(key, data, cache.DefaultExpiration)
+}
+
+// SetIfNotExistWithTTL is like SetIfNotExist but expires the item after ttl
+// instead of the cache's default. The existence check and the write happen
+// under a single lock hold, so concurrent callers can't both pass the
+// check and both write.
+func (c *Cache) SetIfNotExistWithTTL(key string, data interface{}, ttl time.Duration) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if rec, ok := c.readLocked(key); ok && !recordExpired(rec) {
+		return false
+	}
+	if ttl == cache.DefaultExpiration {
+		ttl = c.ttl
+	}
+	return c.writeTTLLocked(key, data, ttl) == nil
+}
+
+// Get is a thread-safe way to lookup items.
+// Every lookup, also touches the item, hence extending it's life, unless it
+// was set with an absolute expiration via SetWithExpiration. The read and
+// the remove-if-expired (or touch) happen under a single lock hold, so a
+// concurrent Set can't land between them and get silently wiped or its
+// fresh record mistaken for the stale one just read.
+func (c *Cache) Get(key string) (*cache.Item, bool) {
+	c.mutex.Lock()
+	rec, ok := c.readLocked(key)
+	if !ok || recordExpired(rec) {
+		if ok {
+			c.removeLocked(key)
+		}
+		c.mutex.Unlock()
+		if ok {
+			c.evict(key, rec.Data)
+		}
+		return nil, false
+	}
+
+	if rec.Fixed {
+		c.mutex.Unlock()
+		return cache.NewItemWithExpiration(rec.Data, rec.Expires), true
+	}
+
+	err := c.writeTTLLocked(key, rec.Data, rec.TTL)
+	c.mutex.Unlock()
+	if err != nil {
+		return nil, false
+	}
+	return cache.NewItem(rec.Data, rec.TTL), true
+}
+
+// Has is a thread-safe way to check if item exists.
+func (c *Cache) Has(key string) bool {
+	rec, ok := c.read(key)
+	return ok && !recordExpired(rec)
+}
+
+// Delete removes key, returning its value if it was present. A key whose
+// record has already expired (but hasn't been swept yet) is removed the
+// same as any other stale file, but is reported as not having existed,
+// matching Get/Has/SetIfNotExist's treatment of expired records - and
+// OnEvicted is not fired for it, since nothing live left the cache.
+func (c *Cache) Delete(key string) (prev interface{}, existed bool) {
+	c.mutex.Lock()
+	rec, ok := c.readLocked(key)
+	removed := c.removeLocked(key)
+	c.mutex.Unlock()
+
+	if !ok || !removed || recordExpired(rec) {
+		return nil, false
+	}
+
+	c.evict(key, rec.Data)
+	return rec.Data, true
+}
+
+// OnEvicted sets a callback invoked whenever an item leaves the cache on
+// its own (a Get that finds it expired) or via Delete. Passing nil
+// disables it. Unlike the memory adapter, the file adapter has no
+// background sweep: the callback fires for an expired item only when a Get
+// finds it expired. Has, Replace, SetIfNotExist and the Increment/Decrement
+// methods treat an expired item as absent but do not fire the callback.
+func (c *Cache) OnEvicted(f func(key string, value interface{})) {
+	c.onEvictedMu.Lock()
+	c.onEvicted = f
+	c.onEvictedMu.Unlock()
+}
+
+func (c *Cache) evict(key string, value interface{}) {
+	c.onEvictedMu.RLock()
+	f := c.onEvicted
+	c.onEvictedMu.RUnlock()
+	if f != nil {
+		f(key, value)
+	}
+}
+
+// Count returns the number of items in the cache.
+func (c *Cache) Count() int {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return 0
+	}
+	return len(entries)
+}
+
+// Clear removes every item from the cache.
+func (c *Cache) Clear() {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		os.Remove(filepath.Join(c.dir, entry.Name()))
+	}
+}
+
+// Save writes every live item to w as a gob-encoded snapshot.
+func (c *Cache) Save(w io.Writer) error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	snapshot := make(map[string]cache.Entry)
+	for _, entry := range entries {
+		key, err := keyFromFilename(entry.Name())
+		if err != nil {
+			continue
+		}
+		rec, ok := c.read(key)
+		if !ok || recordExpired(rec) {
+			continue
+		}
+		snapshot[key] = cache.Entry{Data: rec.Data, TTL: rec.TTL, Fixed: rec.Fixed, Expires: rec.Expires}
+	}
+	return gob.NewEncoder(w).Encode(snapshot)
+}
+
+// SaveFile is like Save but writes to the file at path.
+func (c *Cache) SaveFile(path string) error {
+	return cache.SaveFile(c, path)
+}
+
+// Load merges a snapshot written by Save into the cache, skipping entries
+// that have already expired.
+func (c *Cache) Load(r io.Reader) error {
+	var snapshot map[string]cache.Entry
+	if err := gob.NewDecoder(r).Decode(&snapshot); err != nil {
+		return err
+	}
+
+	for key, entry := range snapshot {
+		if cache.EntryToItem(entry).Expired() {
+			continue
+		}
+		if entry.Fixed {
+			c.writeAt(key, entry.Data, entry.Expires)
+		} else {
+			c.writeTTL(key, entry.Data, entry.TTL)
+		}
+	}
+	return nil
+}
+
+// LoadFile is like Load but reads from the file at path.
+func (c *Cache) LoadFile(path string) error {
+	return cache.LoadFile(c, path)
+}
+
+// GetOrLoad returns the cached item under key, or invokes loader and caches
+// its result if there isn't one. See cache.GetOrLoad for the tombstone and
+// single-flight semantics.
+func (c *Cache) GetOrLoad(key string, loader func() (interface{}, error)) (*cache.Item, error) {
+	return cache.GetOrLoad(c, &c.loadGroup, key, loader)
+}
+
+// Add adds data under key only if it does not already exist, using the
+// cache's default TTL. It is an alias for SetIfNotExist.
+func (c *Cache) Add(key string, data interface{}) bool {
+	return c.SetIfNotExist(key, data)
+}
+
+// Replace overwrites key's data only if it already exists, leaving its
+// TTL/expiration untouched. It returns false if key was not present.
+func (c *Cache) Replace(key string, data interface{}) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	rec, ok := c.readLocked(key)
+	if !ok || recordExpired(rec) {
+		return false
+	}
+	rec.Data = data
+	return c.writeRecordLocked(key, rec) == nil
+}
+
+// numeric applies mutate to the record stored under key, coerced and
+// re-encoded by mutate, writing the result back with the record's
+// TTL/Fixed/Expires untouched.
+func (c *Cache) numeric(key string, mutate func(data interface{}) (interface{}, error)) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	rec, ok := c.readLocked(key)
+	if !ok || recordExpired(rec) {
+		return cache.ErrNotFound
+	}
+
+	data, err := mutate(rec.Data)
+	if err != nil {
+		return err
+	}
+	rec.Data = data
+	return c.writeRecordLocked(key, rec)
+}
+
+// IncrementInt adds delta to the int value stored under key, leaving its
+// TTL/expiration untouched.
+func (c *Cache) IncrementInt(key string, delta int) (int, error) {
+	var result int
+	err := c.numeric(key, func(data interface{}) (interface{}, error) {
+		n, err := cache.IncrementInt(cache.NewItem(data, cache.NoExpiration), key, delta)
+		result = n
+		return n, err
+	})
+	return result, err
+}
+
+// DecrementInt is IncrementInt with delta negated.
+func (c *Cache) DecrementInt(key string, delta int) (int, error) {
+	return c.IncrementInt(key, -delta)
+}
+
+// IncrementInt64 is IncrementInt for int64-valued items.
+func (c *Cache) IncrementInt64(key string, delta int64) (int64, error) {
+	var result int64
+	err := c.numeric(key, func(data interface{}) (interface{}, error) {
+		n, err := cache.IncrementInt64(cache.NewItem(data, cache.NoExpiration), key, delta)
+		result = n
+		return n, err
+	})
+	return result, err
+}
+
+// DecrementInt64 is IncrementInt64 with delta negated.
+func (c *Cache) DecrementInt64(key string, delta int64) (int64, error) {
+	return c.IncrementInt64(key, -delta)
+}
+
+// IncrementUint64 is IncrementInt for uint64-valued items.
+func (c *Cache) IncrementUint64(key string, delta uint64) (uint64, error) {
+	var result uint64
+	err := c.numeric(key, func(data interface{}) (interface{}, error) {
+		n, err := cache.IncrementUint64(cache.NewItem(data, cache.NoExpiration), key, delta)
+		result = n
+		return n, err
+	})
+	return result, err
+}
+
+// DecrementUint64 subtracts delta from the uint64 value stored under key,
+// wrapping on underflow the way any other uint64 subtraction does.
+func (c *Cache) DecrementUint64(key string, delta uint64) (uint64, error) {
+	var result uint64
+	err := c.numeric(key, func(data interface{}) (interface{}, error) {
+		n, err := cache.DecrementUint64(cache.NewItem(data, cache.NoExpiration), key, delta)
+		result = n
+		return n, err
+	})
+	return result, err
+}
+
+// IncrementFloat64 is IncrementInt for float64-valued items.
+func (c *Cache) IncrementFloat64(key string, delta float64) (float64, error) {
+	var result float64
+	err := c.numeric(key, func(data interface{}) (interface{}, error) {
+		n, err := cache.IncrementFloat64(cache.NewItem(data, cache.NoExpiration), key, delta)
+		result = n
+		return n, err
+	})
+	return result, err
+}
+
+// DecrementFloat64 is IncrementFloat64 with delta negated.
+func (c *Cache) DecrementFloat64(key string, delta float64) (float64, error) {
+	return c.IncrementFloat64(key, -delta)
+}
+
+func keyFromFilename(name string) (string, error) {
+	name = name[:len(name)-len(filepath.Ext(name))]
+	b, err := hex.DecodeString(name)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// config is the JSON configuration understood by the "file" adapter, e.g.
+// {"dir": "./cache/file", "interval": 60}.
+type config struct {
+	Dir      string `json:"dir"`
+	Interval int64  `json:"interval"`
+}
+
+type adapter struct{}
+
+func (adapter) Open(configJSON string) (cache.Cache, error) {
+	cfg := config{Dir: "./cache/file", Interval: 60}
+	if configJSON != "" {
+		if err := json.Unmarshal([]byte(configJSON), &cfg); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Dir == "" {
+		return nil, errors.New("file: missing \"dir\" in config")
+	}
+	return New(cfg.Dir, time.Duration(cfg.Interval)*time.Second)
+}
+
+func init() {
+	cache.Register("file", adapter{})
+}
@@ -0,0 +1,223 @@
+package file
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestCache(t *testing.T, ttl time.Duration) *Cache {
+	t.Helper()
+	c, err := New(t.TempDir(), ttl)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+// TestSetGet checks a plain Set/Get round trip.
+func TestSetGet(t *testing.T) {
+	c := newTestCache(t, time.Minute)
+	c.Set("key", "value")
+
+	item, found := c.Get("key")
+	if !found {
+		t.Fatal("expected to find key")
+	}
+	if item.Data() != "value" {
+		t.Fatalf("got %v, want \"value\"", item.Data())
+	}
+}
+
+// TestGetExpired checks a record whose TTL has passed is reported absent
+// and is swept from disk on the access that notices it.
+func TestGetExpired(t *testing.T) {
+	c := newTestCache(t, 20*time.Millisecond)
+	c.Set("key", "value")
+	time.Sleep(40 * time.Millisecond)
+
+	if _, found := c.Get("key"); found {
+		t.Fatal("expected key to have expired")
+	}
+	if c.Count() != 0 {
+		t.Fatal("expired key should also be gone from disk")
+	}
+}
+
+// TestHasDoesNotTouch checks Has reports existence without extending the
+// item's life, matching the memory adapter.
+func TestHasDoesNotTouch(t *testing.T) {
+	c := newTestCache(t, 50*time.Millisecond)
+	c.Set("key", "value")
+
+	if !c.Has("key") {
+		t.Fatal("expected to find key")
+	}
+	time.Sleep(70 * time.Millisecond)
+	if c.Has("key") {
+		t.Fatal("Has must not have extended key's life")
+	}
+}
+
+// TestDeleteReportsExistedAndEvicts checks Delete removes a live key,
+// returns its value, reports existed=true, and fires OnEvicted.
+func TestDeleteReportsExistedAndEvicts(t *testing.T) {
+	c := newTestCache(t, time.Minute)
+	c.Set("key", "value")
+
+	var evictedKey string
+	var evictedValue interface{}
+	c.OnEvicted(func(key string, value interface{}) {
+		evictedKey, evictedValue = key, value
+	})
+
+	prev, existed := c.Delete("key")
+	if !existed || prev != "value" {
+		t.Fatalf("got (%v, %v), want (\"value\", true)", prev, existed)
+	}
+	if evictedKey != "key" || evictedValue != "value" {
+		t.Fatalf("OnEvicted got (%q, %v), want (\"key\", \"value\")", evictedKey, evictedValue)
+	}
+	if c.Has("key") {
+		t.Fatal("expected key to be gone after Delete")
+	}
+}
+
+// TestDeleteExpiredReportsAbsent checks Delete treats an expired-but-
+// unswept record as absent, matching Get/Has/SetIfNotExist, and does not
+// fire OnEvicted for it since nothing live left the cache.
+func TestDeleteExpiredReportsAbsent(t *testing.T) {
+	c := newTestCache(t, 20*time.Millisecond)
+	c.Set("key", "value")
+	time.Sleep(40 * time.Millisecond)
+
+	fired := false
+	c.OnEvicted(func(string, interface{}) { fired = true })
+
+	prev, existed := c.Delete("key")
+	if existed || prev != nil {
+		t.Fatalf("got (%v, %v), want (nil, false)", prev, existed)
+	}
+	if fired {
+		t.Fatal("OnEvicted must not fire for an already-expired record")
+	}
+}
+
+// TestSetIfNotExist checks SetIfNotExist only succeeds while the key is
+// absent or expired.
+func TestSetIfNotExist(t *testing.T) {
+	c := newTestCache(t, 20*time.Millisecond)
+
+	if !c.SetIfNotExist("key", "first") {
+		t.Fatal("expected first SetIfNotExist to succeed")
+	}
+	if c.SetIfNotExist("key", "second") {
+		t.Fatal("expected second SetIfNotExist to fail while key is still live")
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if !c.SetIfNotExist("key", "third") {
+		t.Fatal("expected SetIfNotExist to succeed again once the record expired")
+	}
+	item, _ := c.Get("key")
+	if item.Data() != "third" {
+		t.Fatalf("got %v, want \"third\"", item.Data())
+	}
+}
+
+// TestSetIfNotExistWithTTLConcurrent reproduces the TOCTOU race the
+// maintainer flagged: many goroutines racing SetIfNotExistWithTTL on the
+// same fresh key must yield exactly one success, since the check and the
+// write now happen under a single lock hold.
+func TestSetIfNotExistWithTTLConcurrent(t *testing.T) {
+	c := newTestCache(t, time.Minute)
+
+	const goroutines = 100
+	var successes int32
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			if c.SetIfNotExistWithTTL("key", "value", time.Minute) {
+				mu.Lock()
+				successes++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if successes != 1 {
+		t.Fatalf("got %d successes, want exactly 1", successes)
+	}
+}
+
+// TestReplace checks Replace overwrites an existing key's data while
+// leaving its TTL untouched, and reports false for an absent or expired
+// key.
+func TestReplace(t *testing.T) {
+	c := newTestCache(t, time.Minute)
+	c.Set("key", "original")
+	before, _ := c.read("key")
+
+	if !c.Replace("key", "replaced") {
+		t.Fatal("expected Replace to report the key was found")
+	}
+	if after, _ := c.read("key"); !after.Expires.Equal(before.Expires) {
+		t.Fatalf("got Expires %v after Replace, want unchanged %v", after.Expires, before.Expires)
+	}
+	item, found := c.Get("key")
+	if !found || item.Data() != "replaced" {
+		t.Fatalf("got (found=%v, data=%v), want (true, \"replaced\")", found, item)
+	}
+
+	if c.Replace("missing", "value") {
+		t.Fatal("expected Replace to report false for a key that was never set")
+	}
+}
+
+// TestClearRemovesAllItems checks Clear empties the directory of every
+// item.
+func TestClearRemovesAllItems(t *testing.T) {
+	c := newTestCache(t, time.Minute)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	if c.Count() != 2 {
+		t.Fatalf("got Count() %d, want 2", c.Count())
+	}
+
+	c.Clear()
+	if c.Count() != 0 {
+		t.Fatalf("got Count() %d, want 0", c.Count())
+	}
+	if c.Has("a") || c.Has("b") {
+		t.Fatal("expected both keys to be gone after Clear")
+	}
+}
+
+// TestIncrementInt checks IncrementInt mutates the stored value in place,
+// leaving its TTL untouched.
+func TestIncrementInt(t *testing.T) {
+	c := newTestCache(t, time.Minute)
+	c.Set("counter", 1)
+	before, _ := c.read("counter")
+
+	n, err := c.IncrementInt("counter", 4)
+	if err != nil {
+		t.Fatalf("IncrementInt: %v", err)
+	}
+	if n != 5 {
+		t.Fatalf("got %d, want 5", n)
+	}
+	if after, _ := c.read("counter"); !after.Expires.Equal(before.Expires) {
+		t.Fatalf("got Expires %v after IncrementInt, want unchanged %v", after.Expires, before.Expires)
+	}
+
+	item, _ := c.Get("counter")
+	got, err := item.Int()
+	if err != nil || got != 5 {
+		t.Fatalf("got (%d, %v), want (5, nil)", got, err)
+	}
+}
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"encoding/gob"
+	"errors"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrGone is the sentinel a GetOrLoad loader returns to indicate the value
+// it looked up no longer exists upstream (e.g. a deleted remote resource).
+// GetOrLoad caches this as a tombstone for DefaultTombstoneTTL so repeated
+// lookups don't hammer the loader; wrap it with Tombstone to choose a
+// different TTL.
+var ErrGone = errors.New("cache: gone")
+
+// DefaultTombstoneTTL is how long a tombstone written for a bare ErrGone
+// (not wrapped with Tombstone) stays in the cache.
+const DefaultTombstoneTTL = 30 * time.Second
+
+// tombstone is the marker value stored under a key whose loader reported
+// it gone, so a later Get can short-circuit to ErrGone without calling the
+// loader again.
+type tombstone struct{}
+
+func init() {
+	// So a tombstone survives Save/Load without callers having to register
+	// it themselves.
+	gob.Register(tombstone{})
+}
+
+type tombstoneError struct {
+	err error
+	ttl time.Duration
+}
+
+func (t *tombstoneError) Error() string { return t.err.Error() }
+func (t *tombstoneError) Unwrap() error { return t.err }
+
+// Tombstone wraps err so GetOrLoad caches a negative result for ttl instead
+// of the DefaultTombstoneTTL used for a bare ErrGone.
+func Tombstone(err error, ttl time.Duration) error {
+	return &tombstoneError{err: err, ttl: ttl}
+}
+
+// GetOrLoad is the shared implementation most Cache implementations use to
+// satisfy Cache.GetOrLoad: return the cached item if present, otherwise
+// invoke loader and cache its result under the cache's default TTL.
+// group collapses concurrent misses for the same key into a single loader
+// call; each Cache instance should own its own group.
+//
+// If loader returns ErrGone, or an error wrapped with Tombstone, a
+// tombstone is cached instead of the loader's result, so subsequent
+// lookups return ErrGone immediately without re-invoking loader.
+func GetOrLoad(c Cache, group *singleflight.Group, key string, loader func() (interface{}, error)) (*Item, error) {
+	if item, found := c.Get(key); found {
+		if _, gone := item.Data().(tombstone); gone {
+			return nil, ErrGone
+		}
+		return item, nil
+	}
+
+	_, err, _ := group.Do(key, func() (interface{}, error) {
+		data, loadErr := loader()
+		if loadErr != nil {
+			ttl := DefaultTombstoneTTL
+			gone := errors.Is(loadErr, ErrGone)
+
+			var te *tombstoneError
+			if errors.As(loadErr, &te) {
+				ttl = te.ttl
+				gone = true
+			}
+
+			if gone {
+				c.SetWithTTL(key, tombstone{}, ttl)
+			}
+			return nil, loadErr
+		}
+
+		c.Set(key, data)
+		return data, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	item, found := c.Get(key)
+	if !found {
+		return nil, ErrGone
+	}
+	return item, nil
+}
@@ -0,0 +1,385 @@
+// Package memory implements the "memory" cache.Cache adapter: a
+// synchronised, process-local map of items that auto-expire once stale. It
+// is the direct successor of the pre-interface cache.Cache and the adapter
+// most callers will want by default.
+//
+// Cache uses a single mutex around one map, which is simple but can become
+// a contention point at high QPS. ShardedCache splits the map across many
+// independently-locked shards for that case; reach for it explicitly via
+// NewShardedCache when profiling shows lock contention on Cache.
+package memory
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/go-apibox/cache"
+)
+
+// Cache is a synchronised map of items that auto-expire once stale.
+type Cache struct {
+	mutex           sync.RWMutex
+	ttl             time.Duration
+	cleanupInterval time.Duration
+	items           map[string]*cache.Item
+	onEvicted       func(key string, value interface{})
+	loadGroup       singleflight.Group
+}
+
+var _ cache.Cache = (*Cache)(nil)
+
+// Set is a thread-safe way to add new items to the map, using the cache's
+// default TTL.
+func (c *Cache) Set(key string, data interface{}) {
+	c.SetWithTTL(key, data, cache.DefaultExpiration)
+}
+
+// SetWithTTL is like Set but expires the item after ttl instead of the
+// cache's default.
+func (c *Cache) SetWithTTL(key string, data interface{}, ttl time.Duration) {
+	if ttl == cache.DefaultExpiration {
+		ttl = c.ttl
+	}
+	c.mutex.Lock()
+	c.items[key] = cache.NewItem(data, ttl)
+	c.mutex.Unlock()
+}
+
+// SetWithExpiration is like Set but expires the item at an absolute point
+// in time instead of after a TTL.
+func (c *Cache) SetWithExpiration(key string, data interface{}, at time.Time) {
+	c.mutex.Lock()
+	c.items[key] = cache.NewItemWithExpiration(data, at)
+	c.mutex.Unlock()
+}
+
+// SetIfNotExist is a thread-safe way to add new items to the map.
+// Add successfully only when item is not exists.
+func (c *Cache) SetIfNotExist(key string, data interface{}) bool {
+	return c.SetIfNotExistWithTTL(key, data, cache.DefaultExpiration)
+}
+
+// SetIfNotExistWithTTL is like SetIfNotExist but expires the item after ttl
+// instead of the cache's default.
+func (c *Cache) SetIfNotExistWithTTL(key string, data interface{}, ttl time.Duration) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	item, exists := c.items[key]
+	if exists && !item.Expired() {
+		return false
+	}
+
+	if ttl == cache.DefaultExpiration {
+		ttl = c.ttl
+	}
+	c.items[key] = cache.NewItem(data, ttl)
+
+	return true
+}
+
+// Get is a thread-safe way to lookup items.
+// Every lookup, also touches the item, hence extending it's life.
+func (c *Cache) Get(key string) (item *cache.Item, found bool) {
+	c.mutex.Lock()
+	item, exists := c.items[key]
+	if !exists || item.Expired() {
+		item = nil
+		found = false
+	} else {
+		item.Touch()
+		found = true
+	}
+	c.mutex.Unlock()
+	return
+}
+
+// Has is a thread-safe way to check if item exists.
+func (c *Cache) Has(key string) (found bool) {
+	c.mutex.RLock()
+	item, exists := c.items[key]
+	found = exists && !item.Expired()
+	c.mutex.RUnlock()
+	return
+}
+
+// Delete removes key from the map, returning its value if it was present.
+func (c *Cache) Delete(key string) (prev interface{}, existed bool) {
+	c.mutex.Lock()
+	item, exists := c.items[key]
+	if exists && !item.Expired() {
+		prev, existed = item.Data(), true
+	}
+	delete(c.items, key)
+	onEvicted := c.onEvicted
+	c.mutex.Unlock()
+
+	if existed && onEvicted != nil {
+		onEvicted(key, prev)
+	}
+	return
+}
+
+// OnEvicted sets a callback invoked whenever an item leaves the cache on
+// its own (TTL expiry) or via Delete. Passing nil disables it.
+func (c *Cache) OnEvicted(f func(key string, value interface{})) {
+	c.mutex.Lock()
+	c.onEvicted = f
+	c.mutex.Unlock()
+}
+
+// Count returns the number of items in the cache
+// (helpful for tracking memory leaks).
+func (c *Cache) Count() int {
+	c.mutex.RLock()
+	count := len(c.items)
+	c.mutex.RUnlock()
+	return count
+}
+
+// Clear removes every item from the cache.
+func (c *Cache) Clear() {
+	c.mutex.Lock()
+	c.items = map[string]*cache.Item{}
+	c.mutex.Unlock()
+}
+
+// Save writes every live item to w as a gob-encoded snapshot.
+func (c *Cache) Save(w io.Writer) error {
+	c.mutex.RLock()
+	snapshot := make(map[string]cache.Entry, len(c.items))
+	for key, item := range c.items {
+		if !item.Expired() {
+			snapshot[key] = cache.NewEntry(item)
+		}
+	}
+	c.mutex.RUnlock()
+	return gob.NewEncoder(w).Encode(snapshot)
+}
+
+// SaveFile is like Save but writes to the file at path.
+func (c *Cache) SaveFile(path string) error {
+	return cache.SaveFile(c, path)
+}
+
+// Load merges a snapshot written by Save into the cache, skipping entries
+// that have already expired.
+func (c *Cache) Load(r io.Reader) error {
+	var snapshot map[string]cache.Entry
+	if err := gob.NewDecoder(r).Decode(&snapshot); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for key, entry := range snapshot {
+		item := cache.EntryToItem(entry)
+		if item.Expired() {
+			continue
+		}
+		c.items[key] = item
+	}
+	return nil
+}
+
+// LoadFile is like Load but reads from the file at path.
+func (c *Cache) LoadFile(path string) error {
+	return cache.LoadFile(c, path)
+}
+
+// GetOrLoad returns the cached item under key, or invokes loader and caches
+// its result if there isn't one. See cache.GetOrLoad for the tombstone and
+// single-flight semantics.
+func (c *Cache) GetOrLoad(key string, loader func() (interface{}, error)) (*cache.Item, error) {
+	return cache.GetOrLoad(c, &c.loadGroup, key, loader)
+}
+
+// Add adds data under key only if it does not already exist, using the
+// cache's default TTL. It is an alias for SetIfNotExist.
+func (c *Cache) Add(key string, data interface{}) bool {
+	return c.SetIfNotExist(key, data)
+}
+
+// Replace overwrites key's data only if it already exists, leaving its
+// TTL/expiration untouched. It returns false if key was not present.
+func (c *Cache) Replace(key string, data interface{}) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	item, exists := c.items[key]
+	if !exists || item.Expired() {
+		return false
+	}
+	item.Replace(data)
+	return true
+}
+
+// IncrementInt adds delta to the int value stored under key, leaving its
+// TTL/expiration untouched. The map stays locked for the whole
+// read-modify-write, so a concurrent Delete or TTL sweep can't remove key
+// out from under an in-flight increment.
+func (c *Cache) IncrementInt(key string, delta int) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	item, exists := c.items[key]
+	if !exists || item.Expired() {
+		return 0, cache.ErrNotFound
+	}
+	return cache.IncrementInt(item, key, delta)
+}
+
+// DecrementInt is IncrementInt with delta negated.
+func (c *Cache) DecrementInt(key string, delta int) (int, error) {
+	return c.IncrementInt(key, -delta)
+}
+
+// IncrementInt64 is IncrementInt for int64-valued items.
+func (c *Cache) IncrementInt64(key string, delta int64) (int64, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	item, exists := c.items[key]
+	if !exists || item.Expired() {
+		return 0, cache.ErrNotFound
+	}
+	return cache.IncrementInt64(item, key, delta)
+}
+
+// DecrementInt64 is IncrementInt64 with delta negated.
+func (c *Cache) DecrementInt64(key string, delta int64) (int64, error) {
+	return c.IncrementInt64(key, -delta)
+}
+
+// IncrementUint64 is IncrementInt for uint64-valued items.
+func (c *Cache) IncrementUint64(key string, delta uint64) (uint64, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	item, exists := c.items[key]
+	if !exists || item.Expired() {
+		return 0, cache.ErrNotFound
+	}
+	return cache.IncrementUint64(item, key, delta)
+}
+
+// DecrementUint64 subtracts delta from the uint64 value stored under key,
+// wrapping on underflow the way any other uint64 subtraction does.
+func (c *Cache) DecrementUint64(key string, delta uint64) (uint64, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	item, exists := c.items[key]
+	if !exists || item.Expired() {
+		return 0, cache.ErrNotFound
+	}
+	return cache.DecrementUint64(item, key, delta)
+}
+
+// IncrementFloat64 is IncrementInt for float64-valued items.
+func (c *Cache) IncrementFloat64(key string, delta float64) (float64, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	item, exists := c.items[key]
+	if !exists || item.Expired() {
+		return 0, cache.ErrNotFound
+	}
+	return cache.IncrementFloat64(item, key, delta)
+}
+
+// DecrementFloat64 is IncrementFloat64 with delta negated.
+func (c *Cache) DecrementFloat64(key string, delta float64) (float64, error) {
+	return c.IncrementFloat64(key, -delta)
+}
+
+func (c *Cache) cleanup() {
+	type evicted struct {
+		key   string
+		value interface{}
+	}
+
+	c.mutex.Lock()
+	var removed []evicted
+	for key, item := range c.items {
+		if item.Expired() {
+			removed = append(removed, evicted{key, item.Data()})
+			delete(c.items, key)
+		}
+	}
+	onEvicted := c.onEvicted
+	c.mutex.Unlock()
+
+	if onEvicted != nil {
+		for _, e := range removed {
+			onEvicted(e.key, e.value)
+		}
+	}
+}
+
+func (c *Cache) startCleanupTimer() {
+	duration := c.cleanupInterval
+	if duration < time.Second {
+		duration = time.Second
+	}
+	ticker := time.Tick(duration)
+	go (func() {
+		for {
+			select {
+			case <-ticker:
+				c.cleanup()
+			}
+		}
+	})()
+}
+
+// NewCache is a helper to create instance of the Cache struct.
+func NewCache(expire time.Duration) *Cache {
+	c := &Cache{
+		ttl:             expire,
+		cleanupInterval: expire,
+		items:           map[string]*cache.Item{},
+	}
+	c.startCleanupTimer()
+	return c
+}
+
+// NewCacheEx is a helper to create instance of the Cache struct
+// with specified cleanup interval.
+func NewCacheEx(expire, cleanupInterval time.Duration) *Cache {
+	c := &Cache{
+		ttl:             expire,
+		cleanupInterval: cleanupInterval,
+		items:           map[string]*cache.Item{},
+	}
+	c.startCleanupTimer()
+	return c
+}
+
+// config is the JSON configuration understood by the "memory" adapter, e.g.
+// {"interval": 60}. interval is used both as the default item TTL and the
+// cleanup sweep interval, matching the pre-adapter NewCache(expire) helper.
+type config struct {
+	Interval int64 `json:"interval"`
+}
+
+type adapter struct{}
+
+func (adapter) Open(configJSON string) (cache.Cache, error) {
+	cfg := config{Interval: 60}
+	if configJSON != "" {
+		if err := json.Unmarshal([]byte(configJSON), &cfg); err != nil {
+			return nil, err
+		}
+	}
+	return NewCache(time.Duration(cfg.Interval) * time.Second), nil
+}
+
+func init() {
+	cache.Register("memory", adapter{})
+}
@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-apibox/cache"
+)
+
+// TestCacheGetOrLoadCollapsesConcurrentMisses fires many concurrent
+// GetOrLoad calls for the same missing key and checks the loader ran
+// exactly once, with every caller receiving its result - the
+// singleflight.Group collapsing cache.GetOrLoad is built on.
+func TestCacheGetOrLoadCollapsesConcurrentMisses(t *testing.T) {
+	c := NewCache(time.Minute)
+
+	var calls int32
+	loader := func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(20 * time.Millisecond)
+		return "loaded-value", nil
+	}
+
+	const goroutines = 20
+	var wg sync.WaitGroup
+	results := make([]string, goroutines)
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			item, err := c.GetOrLoad("shared-key", loader)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			s, err := item.String()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			results[i] = s
+		}(i)
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("loader called %d times, want exactly 1", got)
+	}
+	for i, r := range results {
+		if r != "loaded-value" {
+			t.Fatalf("goroutine %d got %q, want %q", i, r, "loaded-value")
+		}
+	}
+}
+
+// TestCacheGetOrLoadTombstonesGone checks that a loader returning ErrGone
+// caches a tombstone instead of a value, and subsequent lookups
+// short-circuit to ErrGone without re-invoking loader.
+func TestCacheGetOrLoadTombstonesGone(t *testing.T) {
+	c := NewCache(time.Minute)
+
+	var calls int32
+	loader := func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, cache.ErrGone
+	}
+
+	if _, err := c.GetOrLoad("gone-key", loader); err != cache.ErrGone {
+		t.Fatalf("first call: got err %v, want ErrGone", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("loader called %d times after first miss, want 1", got)
+	}
+
+	if _, err := c.GetOrLoad("gone-key", loader); err != cache.ErrGone {
+		t.Fatalf("second call: got err %v, want ErrGone", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("loader called %d times after tombstoned lookup, want still 1", got)
+	}
+}
+
+// TestCacheGetOrLoadTombstoneTTLExpires checks a short-TTL tombstone (via
+// cache.Tombstone) stops short-circuiting once it expires, so the loader
+// is asked again.
+func TestCacheGetOrLoadTombstoneTTLExpires(t *testing.T) {
+	c := NewCache(time.Minute)
+
+	var calls int32
+	loader := func() (interface{}, error) {
+		n := atomic.AddInt32(&calls, 1)
+		if n == 1 {
+			return nil, cache.Tombstone(cache.ErrGone, 30*time.Millisecond)
+		}
+		return "recovered", nil
+	}
+
+	if _, err := c.GetOrLoad("short-gone", loader); !errors.Is(err, cache.ErrGone) {
+		t.Fatalf("first call: got err %v, want ErrGone", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	item, err := c.GetOrLoad("short-gone", loader)
+	if err != nil {
+		t.Fatalf("after tombstone expiry: got err %v, want nil", err)
+	}
+	s, err := item.String()
+	if err != nil || s != "recovered" {
+		t.Fatalf("got %q, %v; want \"recovered\", nil", s, err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("loader called %d times, want 2", got)
+	}
+}
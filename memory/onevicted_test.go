@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"testing"
+	"time"
+)
+
+// TestOnEvictedCanCallBackIntoCache checks the callback set via OnEvicted
+// can re-enter the cache it was fired from - its documented contract
+// ("invoked outside of any internal lock ... may safely call back into
+// the cache") - by having it Set a new key from inside itself when Delete
+// evicts a different key. If OnEvicted were fired while c.mutex was still
+// held, this would deadlock instead of completing.
+func TestOnEvictedCanCallBackIntoCache(t *testing.T) {
+	c := NewCache(time.Minute)
+	c.Set("evicted", "gone")
+
+	done := make(chan struct{})
+	c.OnEvicted(func(key string, value interface{}) {
+		c.Set("from-callback", value)
+		close(done)
+	})
+
+	prev, existed := c.Delete("evicted")
+	if !existed || prev != "gone" {
+		t.Fatalf("got (%v, %v), want (\"gone\", true)", prev, existed)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("OnEvicted callback never ran - did Delete fire it under the lock?")
+	}
+
+	item, found := c.Get("from-callback")
+	if !found || item.Data() != "gone" {
+		t.Fatalf("callback's re-entrant Set did not take effect, got found=%v item=%v", found, item)
+	}
+}
+
+// TestOnEvictedFiresOnExpirySweep checks the same re-entrancy holds for
+// the cleanup timer's own eviction path, not just Delete's.
+func TestOnEvictedFiresOnExpirySweep(t *testing.T) {
+	c := NewCache(20 * time.Millisecond)
+	c.Set("expiring", "value")
+
+	done := make(chan struct{})
+	var gotKey string
+	var gotValue interface{}
+	c.OnEvicted(func(key string, value interface{}) {
+		gotKey, gotValue = key, value
+		c.Has("expiring") // re-entrant call must not deadlock
+		close(done)
+	})
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnEvicted callback never ran for the expiry sweep")
+	}
+
+	if gotKey != "expiring" || gotValue != "value" {
+		t.Fatalf("got (%q, %v), want (\"expiring\", \"value\")", gotKey, gotValue)
+	}
+}
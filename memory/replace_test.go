@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"testing"
+	"time"
+)
+
+// TestReplaceOverwritesWithoutResettingExpiration checks Replace swaps in
+// the new value while leaving the item's existing expiration untouched -
+// unlike Set, which would start a fresh TTL window. It uses
+// SetWithExpiration (a fixed, absolute expiry) so that Get's own
+// touch-on-lookup can't mask a Replace-induced reset.
+func TestReplaceOverwritesWithoutResettingExpiration(t *testing.T) {
+	c := NewCache(time.Hour)
+	wantExpires := time.Now().Add(time.Minute)
+	c.SetWithExpiration("key", "original", wantExpires)
+
+	if !c.Replace("key", "replaced") {
+		t.Fatal("expected Replace to report the key was found")
+	}
+
+	item, found := c.Get("key")
+	if !found {
+		t.Fatal("expected to find key after Replace")
+	}
+	if item.Data() != "replaced" {
+		t.Fatalf("got %v, want \"replaced\"", item.Data())
+	}
+	if !item.ExpiresAt().Equal(wantExpires) {
+		t.Fatalf("got ExpiresAt() %v, want unchanged %v", item.ExpiresAt(), wantExpires)
+	}
+}
+
+// TestReplaceReportsFalseWhenAbsent checks Replace refuses to create a new
+// entry for a key that was never set, or whose record has expired.
+func TestReplaceReportsFalseWhenAbsent(t *testing.T) {
+	c := NewCache(time.Hour)
+
+	if c.Replace("missing", "value") {
+		t.Fatal("expected Replace to report false for a key that was never set")
+	}
+	if _, found := c.Get("missing"); found {
+		t.Fatal("Replace must not have created the key")
+	}
+
+	c2 := NewCache(20 * time.Millisecond)
+	c2.Set("expiring", "value")
+	time.Sleep(40 * time.Millisecond)
+
+	if c2.Replace("expiring", "new-value") {
+		t.Fatal("expected Replace to report false for an expired-but-unswept key")
+	}
+}
@@ -0,0 +1,409 @@
+package memory
+
+import (
+	"encoding/gob"
+	"hash/fnv"
+	"io"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/go-apibox/cache"
+)
+
+// DefaultShardCount is the shard count used by NewShardedCache when shards
+// is <= 0.
+const DefaultShardCount = 256
+
+// shard is one partition of a ShardedCache: its own mutex and its own map,
+// so operations against different shards never contend with each other.
+type shard struct {
+	mutex sync.RWMutex
+	items map[string]*cache.Item
+}
+
+// ShardedCache is a Cache split across N independently-locked shards, so a
+// single hot key (or a cleanup sweep) never blocks the whole cache the way
+// a single sync.RWMutex around one map does under high QPS.
+type ShardedCache struct {
+	shards          []*shard
+	ttl             time.Duration
+	cleanupInterval time.Duration
+
+	onEvictedMu sync.RWMutex
+	onEvicted   func(key string, value interface{})
+	loadGroup   singleflight.Group
+}
+
+var _ cache.Cache = (*ShardedCache)(nil)
+
+// NewShardedCache creates a ShardedCache with the given number of shards
+// (DefaultShardCount if shards <= 0), using expire as both the default item
+// TTL and the cleanup sweep interval.
+func NewShardedCache(expire time.Duration, shards int) *ShardedCache {
+	return NewShardedCacheEx(expire, expire, shards)
+}
+
+// NewShardedCacheEx is like NewShardedCache but with a specified cleanup
+// interval independent of the default TTL.
+func NewShardedCacheEx(expire, cleanupInterval time.Duration, shards int) *ShardedCache {
+	if shards <= 0 {
+		shards = DefaultShardCount
+	}
+
+	c := &ShardedCache{
+		shards:          make([]*shard, shards),
+		ttl:             expire,
+		cleanupInterval: cleanupInterval,
+	}
+	for i := range c.shards {
+		c.shards[i] = &shard{items: map[string]*cache.Item{}}
+	}
+	c.startCleanupTimer()
+	return c
+}
+
+// shardFor picks the shard for key using fnv-1a, a fast non-cryptographic
+// hash well suited to short strings.
+func (c *ShardedCache) shardFor(key string) *shard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return c.shards[h.Sum32()%uint32(len(c.shards))]
+}
+
+// Set is a thread-safe way to add new items to the map, using the cache's
+// default TTL.
+func (c *ShardedCache) Set(key string, data interface{}) {
+	c.SetWithTTL(key, data, cache.DefaultExpiration)
+}
+
+// SetWithTTL is like Set but expires the item after ttl instead of the
+// cache's default.
+func (c *ShardedCache) SetWithTTL(key string, data interface{}, ttl time.Duration) {
+	if ttl == cache.DefaultExpiration {
+		ttl = c.ttl
+	}
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	s.items[key] = cache.NewItem(data, ttl)
+	s.mutex.Unlock()
+}
+
+// SetWithExpiration is like Set but expires the item at an absolute point
+// in time instead of after a TTL.
+func (c *ShardedCache) SetWithExpiration(key string, data interface{}, at time.Time) {
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	s.items[key] = cache.NewItemWithExpiration(data, at)
+	s.mutex.Unlock()
+}
+
+// SetIfNotExist is a thread-safe way to add new items to the map.
+// Add successfully only when item is not exists.
+func (c *ShardedCache) SetIfNotExist(key string, data interface{}) bool {
+	return c.SetIfNotExistWithTTL(key, data, cache.DefaultExpiration)
+}
+
+// SetIfNotExistWithTTL is like SetIfNotExist but expires the item after ttl
+// instead of the cache's default.
+func (c *ShardedCache) SetIfNotExistWithTTL(key string, data interface{}, ttl time.Duration) bool {
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item, exists := s.items[key]
+	if exists && !item.Expired() {
+		return false
+	}
+
+	if ttl == cache.DefaultExpiration {
+		ttl = c.ttl
+	}
+	s.items[key] = cache.NewItem(data, ttl)
+
+	return true
+}
+
+// Get is a thread-safe way to lookup items.
+// Every lookup, also touches the item, hence extending it's life.
+func (c *ShardedCache) Get(key string) (item *cache.Item, found bool) {
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	item, exists := s.items[key]
+	if !exists || item.Expired() {
+		item = nil
+		found = false
+	} else {
+		item.Touch()
+		found = true
+	}
+	s.mutex.Unlock()
+	return
+}
+
+// Has is a thread-safe way to check if item exists.
+func (c *ShardedCache) Has(key string) (found bool) {
+	s := c.shardFor(key)
+	s.mutex.RLock()
+	item, exists := s.items[key]
+	found = exists && !item.Expired()
+	s.mutex.RUnlock()
+	return
+}
+
+// Delete removes key from the map, returning its value if it was present.
+func (c *ShardedCache) Delete(key string) (prev interface{}, existed bool) {
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	item, exists := s.items[key]
+	if exists && !item.Expired() {
+		prev, existed = item.Data(), true
+	}
+	delete(s.items, key)
+	s.mutex.Unlock()
+
+	if existed {
+		c.evict(key, prev)
+	}
+	return
+}
+
+// OnEvicted sets a callback invoked whenever an item leaves the cache on
+// its own (TTL expiry) or via Delete. Passing nil disables it.
+func (c *ShardedCache) OnEvicted(f func(key string, value interface{})) {
+	c.onEvictedMu.Lock()
+	c.onEvicted = f
+	c.onEvictedMu.Unlock()
+}
+
+func (c *ShardedCache) evict(key string, value interface{}) {
+	c.onEvictedMu.RLock()
+	f := c.onEvicted
+	c.onEvictedMu.RUnlock()
+	if f != nil {
+		f(key, value)
+	}
+}
+
+// Count returns the number of items in the cache. Each shard is counted
+// under its own lock, one at a time, rather than freezing the whole cache.
+func (c *ShardedCache) Count() int {
+	count := 0
+	for _, s := range c.shards {
+		s.mutex.RLock()
+		count += len(s.items)
+		s.mutex.RUnlock()
+	}
+	return count
+}
+
+// Clear removes every item from the cache.
+func (c *ShardedCache) Clear() {
+	for _, s := range c.shards {
+		s.mutex.Lock()
+		s.items = map[string]*cache.Item{}
+		s.mutex.Unlock()
+	}
+}
+
+// Save writes every live item to w as a gob-encoded snapshot. Each shard is
+// read under its own lock, one at a time, rather than freezing the whole
+// cache.
+func (c *ShardedCache) Save(w io.Writer) error {
+	snapshot := make(map[string]cache.Entry)
+	for _, s := range c.shards {
+		s.mutex.RLock()
+		for key, item := range s.items {
+			if !item.Expired() {
+				snapshot[key] = cache.NewEntry(item)
+			}
+		}
+		s.mutex.RUnlock()
+	}
+	return gob.NewEncoder(w).Encode(snapshot)
+}
+
+// SaveFile is like Save but writes to the file at path.
+func (c *ShardedCache) SaveFile(path string) error {
+	return cache.SaveFile(c, path)
+}
+
+// Load merges a snapshot written by Save into the cache, skipping entries
+// that have already expired.
+func (c *ShardedCache) Load(r io.Reader) error {
+	var snapshot map[string]cache.Entry
+	if err := gob.NewDecoder(r).Decode(&snapshot); err != nil {
+		return err
+	}
+
+	for key, entry := range snapshot {
+		item := cache.EntryToItem(entry)
+		if item.Expired() {
+			continue
+		}
+		s := c.shardFor(key)
+		s.mutex.Lock()
+		s.items[key] = item
+		s.mutex.Unlock()
+	}
+	return nil
+}
+
+// LoadFile is like Load but reads from the file at path.
+func (c *ShardedCache) LoadFile(path string) error {
+	return cache.LoadFile(c, path)
+}
+
+// GetOrLoad returns the cached item under key, or invokes loader and caches
+// its result if there isn't one. See cache.GetOrLoad for the tombstone and
+// single-flight semantics.
+func (c *ShardedCache) GetOrLoad(key string, loader func() (interface{}, error)) (*cache.Item, error) {
+	return cache.GetOrLoad(c, &c.loadGroup, key, loader)
+}
+
+// Add adds data under key only if it does not already exist, using the
+// cache's default TTL. It is an alias for SetIfNotExist.
+func (c *ShardedCache) Add(key string, data interface{}) bool {
+	return c.SetIfNotExist(key, data)
+}
+
+// Replace overwrites key's data only if it already exists, leaving its
+// TTL/expiration untouched. It returns false if key was not present.
+func (c *ShardedCache) Replace(key string, data interface{}) bool {
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item, exists := s.items[key]
+	if !exists || item.Expired() {
+		return false
+	}
+	item.Replace(data)
+	return true
+}
+
+// IncrementInt adds delta to the int value stored under key, leaving its
+// TTL/expiration untouched. The shard stays locked for the whole
+// read-modify-write, so a concurrent Delete or TTL sweep can't remove key
+// out from under an in-flight increment.
+func (c *ShardedCache) IncrementInt(key string, delta int) (int, error) {
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item, exists := s.items[key]
+	if !exists || item.Expired() {
+		return 0, cache.ErrNotFound
+	}
+	return cache.IncrementInt(item, key, delta)
+}
+
+// DecrementInt is IncrementInt with delta negated.
+func (c *ShardedCache) DecrementInt(key string, delta int) (int, error) {
+	return c.IncrementInt(key, -delta)
+}
+
+// IncrementInt64 is IncrementInt for int64-valued items.
+func (c *ShardedCache) IncrementInt64(key string, delta int64) (int64, error) {
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item, exists := s.items[key]
+	if !exists || item.Expired() {
+		return 0, cache.ErrNotFound
+	}
+	return cache.IncrementInt64(item, key, delta)
+}
+
+// DecrementInt64 is IncrementInt64 with delta negated.
+func (c *ShardedCache) DecrementInt64(key string, delta int64) (int64, error) {
+	return c.IncrementInt64(key, -delta)
+}
+
+// IncrementUint64 is IncrementInt for uint64-valued items.
+func (c *ShardedCache) IncrementUint64(key string, delta uint64) (uint64, error) {
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item, exists := s.items[key]
+	if !exists || item.Expired() {
+		return 0, cache.ErrNotFound
+	}
+	return cache.IncrementUint64(item, key, delta)
+}
+
+// DecrementUint64 subtracts delta from the uint64 value stored under key,
+// wrapping on underflow the way any other uint64 subtraction does.
+func (c *ShardedCache) DecrementUint64(key string, delta uint64) (uint64, error) {
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item, exists := s.items[key]
+	if !exists || item.Expired() {
+		return 0, cache.ErrNotFound
+	}
+	return cache.DecrementUint64(item, key, delta)
+}
+
+// IncrementFloat64 is IncrementInt for float64-valued items.
+func (c *ShardedCache) IncrementFloat64(key string, delta float64) (float64, error) {
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item, exists := s.items[key]
+	if !exists || item.Expired() {
+		return 0, cache.ErrNotFound
+	}
+	return cache.IncrementFloat64(item, key, delta)
+}
+
+// DecrementFloat64 is IncrementFloat64 with delta negated.
+func (c *ShardedCache) DecrementFloat64(key string, delta float64) (float64, error) {
+	return c.IncrementFloat64(key, -delta)
+}
+
+// cleanup sweeps expired items one shard at a time, so it never blocks
+// lookups against the other shards for longer than a single shard's sweep.
+func (c *ShardedCache) cleanup() {
+	type evicted struct {
+		key   string
+		value interface{}
+	}
+
+	var removed []evicted
+	for _, s := range c.shards {
+		s.mutex.Lock()
+		for key, item := range s.items {
+			if item.Expired() {
+				removed = append(removed, evicted{key, item.Data()})
+				delete(s.items, key)
+			}
+		}
+		s.mutex.Unlock()
+	}
+
+	for _, e := range removed {
+		c.evict(e.key, e.value)
+	}
+}
+
+func (c *ShardedCache) startCleanupTimer() {
+	duration := c.cleanupInterval
+	if duration < time.Second {
+		duration = time.Second
+	}
+	ticker := time.Tick(duration)
+	go (func() {
+		for {
+			select {
+			case <-ticker:
+				c.cleanup()
+			}
+		}
+	})()
+}
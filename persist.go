@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"encoding/gob"
+	"os"
+	"time"
+)
+
+// Entry is the exported, gob-encodable snapshot of a single Item, used by
+// Cache implementations' Save/Load. It carries the same expiration
+// bookkeeping as Item itself, since Item's own fields are unexported.
+type Entry struct {
+	Data    interface{}
+	TTL     time.Duration
+	Fixed   bool
+	Expires time.Time
+}
+
+// NewEntry converts item into its serializable Entry form.
+func NewEntry(item *Item) Entry {
+	item.RLock()
+	defer item.RUnlock()
+
+	entry := Entry{Data: item.data, TTL: item.ttl, Fixed: item.fixed}
+	if item.expires != nil {
+		entry.Expires = *item.expires
+	}
+	return entry
+}
+
+// EntryToItem reconstructs the Item an Entry was built from, preserving
+// its captured Expires exactly rather than starting a fresh TTL window -
+// otherwise an item saved a moment before expiring would come back to
+// life with a brand new lease, and Load's "skip already-expired entries"
+// check would never see it as expired. entry.TTL is kept for non-fixed
+// items so a later Touch() (e.g. from Get) still extends the item the
+// normal way.
+func EntryToItem(entry Entry) *Item {
+	if entry.Fixed {
+		return NewItemWithExpiration(entry.Data, entry.Expires)
+	}
+
+	item := &Item{data: entry.Data, ttl: entry.TTL}
+	if !entry.Expires.IsZero() {
+		expires := entry.Expires
+		item.expires = &expires
+	}
+	return item
+}
+
+// RegisterType registers value's concrete type with encoding/gob, which
+// Save/Load (and the file and redis adapters, which persist items the same
+// way) require for any type stored as Item data - Go cannot gob-decode into
+// an interface{} field otherwise. Call it once per concrete type at program
+// startup, mirroring gob.Register.
+func RegisterType(value interface{}) {
+	gob.Register(value)
+}
+
+// SaveFile is a convenience most Cache implementations use to satisfy
+// Cache.SaveFile in terms of their own Save.
+func SaveFile(c Cache, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return c.Save(f)
+}
+
+// LoadFile is a convenience most Cache implementations use to satisfy
+// Cache.LoadFile in terms of their own Load.
+func LoadFile(c Cache, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return c.Load(f)
+}
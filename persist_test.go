@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+// TestEntryToItemPreservesCapturedExpiry checks that a non-fixed entry's
+// captured Expires survives the NewEntry/EntryToItem round trip exactly,
+// rather than being reset to a fresh now+TTL window - the bug fixed by
+// 3f72f35. An entry snapshotted moments before expiring must come back
+// already expired, and one still alive must keep its true remaining
+// lease, not a brand new full TTL.
+func TestEntryToItemPreservesCapturedExpiry(t *testing.T) {
+	original := NewItem("value", 500*time.Millisecond)
+	time.Sleep(350 * time.Millisecond)
+
+	entry := NewEntry(original)
+	reconstructed := EntryToItem(entry)
+
+	if reconstructed.Expired() {
+		t.Fatal("entry captured while still alive should reconstruct as still alive")
+	}
+	if got := reconstructed.ExpiresAt(); !got.Equal(entry.Expires) {
+		t.Fatalf("got ExpiresAt() %v, want the captured Expires %v", got, entry.Expires)
+	}
+
+	// The remaining lease must be the original's true remaining time
+	// (~150ms), not a fresh 500ms window.
+	remaining := time.Until(reconstructed.ExpiresAt())
+	if remaining > 250*time.Millisecond {
+		t.Fatalf("remaining lease %v looks like a reset full TTL, not the preserved remainder", remaining)
+	}
+}
+
+// TestEntryToItemDropsAlreadyExpiredEntry checks that an entry captured
+// with an expiry already in the past reconstructs as expired, so Load's
+// "skip already-expired entries" check actually triggers for non-fixed
+// entries.
+func TestEntryToItemDropsAlreadyExpiredEntry(t *testing.T) {
+	original := NewItem("value", 50*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+
+	entry := NewEntry(original)
+	reconstructed := EntryToItem(entry)
+
+	if !reconstructed.Expired() {
+		t.Fatal("entry captured after its expiry passed should reconstruct as expired")
+	}
+}
+
+// TestEntryToItemPreservesFixedExpiry checks the Fixed (absolute
+// expiration) path continues to preserve Expires exactly, same as before
+// 3f72f35 - only the non-fixed path had the bug.
+func TestEntryToItemPreservesFixedExpiry(t *testing.T) {
+	at := time.Now().Add(200 * time.Millisecond)
+	original := NewItemWithExpiration("value", at)
+
+	entry := NewEntry(original)
+	reconstructed := EntryToItem(entry)
+
+	if !reconstructed.ExpiresAt().Equal(at) {
+		t.Fatalf("got ExpiresAt() %v, want %v", reconstructed.ExpiresAt(), at)
+	}
+	if reconstructed.Expired() {
+		t.Fatal("expected the fixed entry to still be alive")
+	}
+}
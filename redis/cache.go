@@ -0,0 +1,484 @@
+// Package redis implements the "redis" cache.Cache adapter, backing items
+// with a shared Redis instance so the cache can be reused across processes.
+// TTLs are enforced by Redis itself via EXPIRE rather than a local cleanup
+// loop. Because an Item's data is interface{}, callers must
+// cache.RegisterType the concrete types they store, the same way
+// encoding/gob requires for any interface value.
+//
+// IMPORTANT: this adapter does not namespace or prefix the keys it writes,
+// so Count, Clear and Save operate on the entire selected Redis database,
+// not just the keys this cache wrote. Point it at a Redis database
+// dedicated to this cache instance - never one shared with other data -
+// or Clear will silently wipe unrelated keys and Count/Save will report on
+// more than this cache holds.
+package redis
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/go-apibox/cache"
+)
+
+// record is the value shape stored at each Redis key. TTL and Fixed mirror
+// cache.Item's own bookkeeping so a Get can decide whether to extend the
+// key's Redis expiry the same way the memory adapter extends an Item.
+type record struct {
+	Data  interface{}
+	TTL   time.Duration
+	Fixed bool
+}
+
+// Cache is a cache.Cache backed by a Redis instance.
+type Cache struct {
+	client *goredis.Client
+	ttl    time.Duration
+
+	onEvictedMu sync.RWMutex
+	onEvicted   func(key string, value interface{})
+	loadGroup   singleflight.Group
+}
+
+var _ cache.Cache = (*Cache)(nil)
+
+// New wraps an existing Redis client as a Cache, with items expiring ttl
+// after they are last touched.
+func New(client *goredis.Client, ttl time.Duration) *Cache {
+	return &Cache{client: client, ttl: ttl}
+}
+
+func encode(rec record) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(rec); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decode(b []byte) (record, error) {
+	var rec record
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&rec); err != nil {
+		return record{}, err
+	}
+	return rec, nil
+}
+
+// Set is a thread-safe way to add or overwrite an item, using the cache's
+// default TTL.
+func (c *Cache) Set(key string, data interface{}) {
+	c.SetWithTTL(key, data, cache.DefaultExpiration)
+}
+
+// SetWithTTL is like Set but expires the item after ttl instead of the
+// cache's default. Expiry is enforced by Redis itself via EXPIRE.
+func (c *Cache) SetWithTTL(key string, data interface{}, ttl time.Duration) {
+	if ttl == cache.DefaultExpiration {
+		ttl = c.ttl
+	}
+	b, err := encode(record{Data: data, TTL: ttl})
+	if err != nil {
+		return
+	}
+
+	ctx := context.Background()
+	if ttl == cache.NoExpiration {
+		c.client.Set(ctx, key, b, 0)
+		return
+	}
+	c.client.Set(ctx, key, b, ttl)
+}
+
+// SetWithExpiration is like Set but expires the item at an absolute point
+// in time instead of after a TTL, via Redis's PEXPIREAT.
+func (c *Cache) SetWithExpiration(key string, data interface{}, at time.Time) {
+	b, err := encode(record{Data: data, Fixed: true})
+	if err != nil {
+		return
+	}
+
+	ctx := context.Background()
+	c.client.Set(ctx, key, b, 0)
+	c.client.PExpireAt(ctx, key, at)
+}
+
+// SetIfNotExist adds data under key only if it does not already exist,
+// using the cache's default TTL. It returns false otherwise.
+func (c *Cache) SetIfNotExist(key string, data interface{}) bool {
+	return c.SetIfNotExistWithTTL(key, data, cache.DefaultExpiration)
+}
+
+// SetIfNotExistWithTTL is like SetIfNotExist but expires the item after ttl
+// instead of the cache's default.
+func (c *Cache) SetIfNotExistWithTTL(key string, data interface{}, ttl time.Duration) bool {
+	if ttl == cache.DefaultExpiration {
+		ttl = c.ttl
+	}
+	b, err := encode(record{Data: data, TTL: ttl})
+	if err != nil {
+		return false
+	}
+
+	var expiry time.Duration
+	if ttl != cache.NoExpiration {
+		expiry = ttl
+	}
+	ok, err := c.client.SetNX(context.Background(), key, b, expiry).Result()
+	return err == nil && ok
+}
+
+// Get is a thread-safe way to lookup items.
+// Every lookup, also touches the item, hence extending it's life, unless it
+// was set with an absolute expiration via SetWithExpiration.
+func (c *Cache) Get(key string) (*cache.Item, bool) {
+	ctx := context.Background()
+
+	b, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil, false
+	}
+
+	rec, err := decode(b)
+	if err != nil {
+		return nil, false
+	}
+
+	if rec.Fixed {
+		pttl, err := c.client.PTTL(ctx, key).Result()
+		if err != nil {
+			return nil, false
+		}
+		return cache.NewItemWithExpiration(rec.Data, time.Now().Add(pttl)), true
+	}
+
+	if rec.TTL != cache.NoExpiration {
+		c.client.Expire(ctx, key, rec.TTL)
+	}
+	return cache.NewItem(rec.Data, rec.TTL), true
+}
+
+// Has is a thread-safe way to check if item exists.
+func (c *Cache) Has(key string) bool {
+	n, err := c.client.Exists(context.Background(), key).Result()
+	return err == nil && n > 0
+}
+
+// Delete removes key, returning its value if it was present.
+func (c *Cache) Delete(key string) (prev interface{}, existed bool) {
+	ctx := context.Background()
+
+	b, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil, false
+	}
+	rec, err := decode(b)
+	if err != nil {
+		return nil, false
+	}
+
+	n, err := c.client.Del(ctx, key).Result()
+	if err != nil || n == 0 {
+		return nil, false
+	}
+
+	c.evict(key, rec.Data)
+	return rec.Data, true
+}
+
+// OnEvicted sets a callback invoked whenever an item is removed via
+// Delete. Passing nil disables it. Redis enforces TTL expiry itself; this
+// adapter has no way to observe that happening (it would require
+// subscribing to Redis keyspace notifications), so the callback does not
+// fire for items that simply expire on the server.
+func (c *Cache) OnEvicted(f func(key string, value interface{})) {
+	c.onEvictedMu.Lock()
+	c.onEvicted = f
+	c.onEvictedMu.Unlock()
+}
+
+func (c *Cache) evict(key string, value interface{}) {
+	c.onEvictedMu.RLock()
+	f := c.onEvicted
+	c.onEvictedMu.RUnlock()
+	if f != nil {
+		f(key, value)
+	}
+}
+
+// Count returns the number of keys in the selected Redis database. It is
+// not scoped to keys this cache wrote - see the package doc comment's
+// dedicated-database requirement.
+func (c *Cache) Count() int {
+	n, err := c.client.DBSize(context.Background()).Result()
+	if err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// Clear removes every item from the selected Redis database. This wipes
+// the whole database, not just keys this cache wrote - see the package
+// doc comment's dedicated-database requirement.
+func (c *Cache) Clear() {
+	c.client.FlushDB(context.Background())
+}
+
+// Save writes every live item to w as a gob-encoded snapshot, discovering
+// keys via SCAN so it does not block the server the way KEYS would. It
+// snapshots every key in the selected Redis database, not just keys this
+// cache wrote - see the package doc comment's dedicated-database
+// requirement.
+func (c *Cache) Save(w io.Writer) error {
+	ctx := context.Background()
+	snapshot := make(map[string]cache.Entry)
+
+	iter := c.client.Scan(ctx, 0, "", 0).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		b, err := c.client.Get(ctx, key).Bytes()
+		if err != nil {
+			continue
+		}
+		rec, err := decode(b)
+		if err != nil {
+			continue
+		}
+
+		entry := cache.Entry{Data: rec.Data, TTL: rec.TTL, Fixed: rec.Fixed}
+		if rec.Fixed {
+			pttl, err := c.client.PTTL(ctx, key).Result()
+			if err != nil {
+				continue
+			}
+			entry.Expires = time.Now().Add(pttl)
+		}
+		snapshot[key] = entry
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	return gob.NewEncoder(w).Encode(snapshot)
+}
+
+// SaveFile is like Save but writes to the file at path.
+func (c *Cache) SaveFile(path string) error {
+	return cache.SaveFile(c, path)
+}
+
+// Load merges a snapshot written by Save into the cache, skipping entries
+// that have already expired.
+func (c *Cache) Load(r io.Reader) error {
+	var snapshot map[string]cache.Entry
+	if err := gob.NewDecoder(r).Decode(&snapshot); err != nil {
+		return err
+	}
+
+	for key, entry := range snapshot {
+		if cache.EntryToItem(entry).Expired() {
+			continue
+		}
+		if entry.Fixed {
+			c.SetWithExpiration(key, entry.Data, entry.Expires)
+		} else {
+			c.SetWithTTL(key, entry.Data, entry.TTL)
+		}
+	}
+	return nil
+}
+
+// LoadFile is like Load but reads from the file at path.
+func (c *Cache) LoadFile(path string) error {
+	return cache.LoadFile(c, path)
+}
+
+// GetOrLoad returns the cached item under key, or invokes loader and caches
+// its result if there isn't one. See cache.GetOrLoad for the tombstone and
+// single-flight semantics.
+func (c *Cache) GetOrLoad(key string, loader func() (interface{}, error)) (*cache.Item, error) {
+	return cache.GetOrLoad(c, &c.loadGroup, key, loader)
+}
+
+// Add adds data under key only if it does not already exist, using the
+// cache's default TTL. It is an alias for SetIfNotExist.
+func (c *Cache) Add(key string, data interface{}) bool {
+	return c.SetIfNotExist(key, data)
+}
+
+// Replace overwrites key's data only if it already exists, preserving its
+// current Redis TTL via KEEPTTL rather than resetting it the way Set does.
+// It returns false if key was not present.
+func (c *Cache) Replace(key string, data interface{}) bool {
+	ctx := context.Background()
+
+	b, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		return false
+	}
+	rec, err := decode(b)
+	if err != nil {
+		return false
+	}
+
+	rec.Data = data
+	b, err = encode(rec)
+	if err != nil {
+		return false
+	}
+	return c.client.Set(ctx, key, b, goredis.KeepTTL).Err() == nil
+}
+
+// numeric applies mutate to the record stored under key inside a
+// WATCH/MULTI transaction, so concurrent callers across processes never
+// race on the same key, and writes the result back with KEEPTTL so the
+// key's Redis TTL is left untouched.
+func (c *Cache) numeric(key string, mutate func(data interface{}) (interface{}, error)) error {
+	ctx := context.Background()
+
+	return c.client.Watch(ctx, func(tx *goredis.Tx) error {
+		b, err := tx.Get(ctx, key).Bytes()
+		if err == goredis.Nil {
+			return cache.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		rec, err := decode(b)
+		if err != nil {
+			return err
+		}
+
+		data, err := mutate(rec.Data)
+		if err != nil {
+			return err
+		}
+		rec.Data = data
+		encoded, err := encode(rec)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+			pipe.Set(ctx, key, encoded, goredis.KeepTTL)
+			return nil
+		})
+		return err
+	}, key)
+}
+
+// IncrementInt adds delta to the int value stored under key, leaving its
+// TTL untouched.
+func (c *Cache) IncrementInt(key string, delta int) (int, error) {
+	var result int
+	err := c.numeric(key, func(data interface{}) (interface{}, error) {
+		n, err := cache.IncrementInt(cache.NewItem(data, cache.NoExpiration), key, delta)
+		result = n
+		return n, err
+	})
+	return result, err
+}
+
+// DecrementInt is IncrementInt with delta negated.
+func (c *Cache) DecrementInt(key string, delta int) (int, error) {
+	return c.IncrementInt(key, -delta)
+}
+
+// IncrementInt64 is IncrementInt for int64-valued items.
+func (c *Cache) IncrementInt64(key string, delta int64) (int64, error) {
+	var result int64
+	err := c.numeric(key, func(data interface{}) (interface{}, error) {
+		n, err := cache.IncrementInt64(cache.NewItem(data, cache.NoExpiration), key, delta)
+		result = n
+		return n, err
+	})
+	return result, err
+}
+
+// DecrementInt64 is IncrementInt64 with delta negated.
+func (c *Cache) DecrementInt64(key string, delta int64) (int64, error) {
+	return c.IncrementInt64(key, -delta)
+}
+
+// IncrementUint64 is IncrementInt for uint64-valued items.
+func (c *Cache) IncrementUint64(key string, delta uint64) (uint64, error) {
+	var result uint64
+	err := c.numeric(key, func(data interface{}) (interface{}, error) {
+		n, err := cache.IncrementUint64(cache.NewItem(data, cache.NoExpiration), key, delta)
+		result = n
+		return n, err
+	})
+	return result, err
+}
+
+// DecrementUint64 subtracts delta from the uint64 value stored under key,
+// wrapping on underflow the way any other uint64 subtraction does.
+func (c *Cache) DecrementUint64(key string, delta uint64) (uint64, error) {
+	var result uint64
+	err := c.numeric(key, func(data interface{}) (interface{}, error) {
+		n, err := cache.DecrementUint64(cache.NewItem(data, cache.NoExpiration), key, delta)
+		result = n
+		return n, err
+	})
+	return result, err
+}
+
+// IncrementFloat64 is IncrementInt for float64-valued items.
+func (c *Cache) IncrementFloat64(key string, delta float64) (float64, error) {
+	var result float64
+	err := c.numeric(key, func(data interface{}) (interface{}, error) {
+		n, err := cache.IncrementFloat64(cache.NewItem(data, cache.NoExpiration), key, delta)
+		result = n
+		return n, err
+	})
+	return result, err
+}
+
+// DecrementFloat64 is IncrementFloat64 with delta negated.
+func (c *Cache) DecrementFloat64(key string, delta float64) (float64, error) {
+	return c.IncrementFloat64(key, -delta)
+}
+
+// config is the JSON configuration understood by the "redis" adapter, e.g.
+// {"conn":"127.0.0.1:6379","dbNum":"0","password":"","interval":60}.
+type config struct {
+	Conn     string `json:"conn"`
+	DBNum    string `json:"dbNum"`
+	Password string `json:"password"`
+	Interval int64  `json:"interval"`
+}
+
+type adapter struct{}
+
+func (adapter) Open(configJSON string) (cache.Cache, error) {
+	cfg := config{Conn: "127.0.0.1:6379", DBNum: "0", Interval: 60}
+	if configJSON != "" {
+		if err := json.Unmarshal([]byte(configJSON), &cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	dbNum, err := strconv.Atoi(cfg.DBNum)
+	if err != nil {
+		return nil, fmt.Errorf("redis: invalid dbNum %q: %w", cfg.DBNum, err)
+	}
+
+	client := goredis.NewClient(&goredis.Options{
+		Addr:     cfg.Conn,
+		Password: cfg.Password,
+		DB:       dbNum,
+	})
+	return New(client, time.Duration(cfg.Interval)*time.Second), nil
+}
+
+func init() {
+	cache.Register("redis", adapter{})
+}
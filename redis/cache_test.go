@@ -0,0 +1,146 @@
+package redis
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// newTestCache connects to a Redis instance (REDIS_ADDR, or
+// 127.0.0.1:6379 by default) and selects a high-numbered scratch database
+// so Clear/FlushDB can't touch anything real. It skips the test if no
+// server answers - there is no live Redis or miniredis dependency
+// available in every environment this runs in.
+func newTestCache(t *testing.T, ttl time.Duration) *Cache {
+	t.Helper()
+
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "127.0.0.1:6379"
+	}
+	client := goredis.NewClient(&goredis.Options{Addr: addr, DB: 15})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("no redis server reachable at %s: %v", addr, err)
+	}
+
+	client.FlushDB(context.Background())
+	t.Cleanup(func() { client.FlushDB(context.Background()) })
+
+	return New(client, ttl)
+}
+
+// TestSetGet checks a plain Set/Get round trip.
+func TestSetGet(t *testing.T) {
+	c := newTestCache(t, time.Minute)
+	c.Set("key", "value")
+
+	item, found := c.Get("key")
+	if !found {
+		t.Fatal("expected to find key")
+	}
+	if item.Data() != "value" {
+		t.Fatalf("got %v, want \"value\"", item.Data())
+	}
+}
+
+// TestGetExpired checks a record whose TTL has passed (enforced by Redis
+// itself) is reported absent.
+func TestGetExpired(t *testing.T) {
+	c := newTestCache(t, 50*time.Millisecond)
+	c.Set("key", "value")
+	time.Sleep(150 * time.Millisecond)
+
+	if _, found := c.Get("key"); found {
+		t.Fatal("expected key to have expired")
+	}
+}
+
+// TestSetIfNotExist checks SetIfNotExist only succeeds while the key is
+// absent, using Redis's native SETNX.
+func TestSetIfNotExist(t *testing.T) {
+	c := newTestCache(t, time.Minute)
+
+	if !c.SetIfNotExist("key", "first") {
+		t.Fatal("expected first SetIfNotExist to succeed")
+	}
+	if c.SetIfNotExist("key", "second") {
+		t.Fatal("expected second SetIfNotExist to fail while key is still live")
+	}
+}
+
+// TestDeleteReportsExistedAndEvicts checks Delete removes a live key,
+// returns its value, reports existed=true, and fires OnEvicted.
+func TestDeleteReportsExistedAndEvicts(t *testing.T) {
+	c := newTestCache(t, time.Minute)
+	c.Set("key", "value")
+
+	var evictedKey string
+	var evictedValue interface{}
+	c.OnEvicted(func(key string, value interface{}) {
+		evictedKey, evictedValue = key, value
+	})
+
+	prev, existed := c.Delete("key")
+	if !existed || prev != "value" {
+		t.Fatalf("got (%v, %v), want (\"value\", true)", prev, existed)
+	}
+	if evictedKey != "key" || evictedValue != "value" {
+		t.Fatalf("OnEvicted got (%q, %v), want (\"key\", \"value\")", evictedKey, evictedValue)
+	}
+	if c.Has("key") {
+		t.Fatal("expected key to be gone after Delete")
+	}
+}
+
+// TestReplacePreservesTTL checks Replace overwrites an existing key's data
+// via KEEPTTL, leaving its Redis expiry untouched, and reports false for
+// an absent key.
+func TestReplacePreservesTTL(t *testing.T) {
+	c := newTestCache(t, time.Minute)
+	c.SetWithTTL("key", "original", time.Hour)
+
+	if !c.Replace("key", "replaced") {
+		t.Fatal("expected Replace to report the key was found")
+	}
+	if ttl := c.client.PTTL(context.Background(), "key").Val(); ttl <= time.Minute {
+		t.Fatalf("got TTL %v after Replace, want the original ~1h kept", ttl)
+	}
+	item, found := c.Get("key")
+	if !found || item.Data() != "replaced" {
+		t.Fatalf("got (found=%v, data=%v), want (true, \"replaced\")", found, item)
+	}
+
+	if c.Replace("missing", "value") {
+		t.Fatal("expected Replace to report false for a key that was never set")
+	}
+}
+
+// TestIncrementInt checks IncrementInt mutates the stored value
+// transactionally, leaving its TTL untouched.
+func TestIncrementInt(t *testing.T) {
+	c := newTestCache(t, time.Minute)
+	c.Set("counter", 1)
+
+	n, err := c.IncrementInt("counter", 4)
+	if err != nil {
+		t.Fatalf("IncrementInt: %v", err)
+	}
+	if n != 5 {
+		t.Fatalf("got %d, want 5", n)
+	}
+	if ttl := c.client.PTTL(context.Background(), "counter").Val(); ttl <= 0 {
+		t.Fatalf("got TTL %v after IncrementInt, want the key's expiry kept", ttl)
+	}
+
+	item, _ := c.Get("counter")
+	got, err := item.Int()
+	if err != nil || got != 5 {
+		t.Fatalf("got (%d, %v), want (5, nil)", got, err)
+	}
+}
@@ -0,0 +1,275 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrKeyCollision is returned by StructCache.Put when the value being put
+// would collide with a different, still-live entry on one of the cache's
+// declared indexes. The cache is left unchanged.
+var ErrKeyCollision = errors.New("cache: key collision")
+
+// structIndex is one declared lookup key: the struct field names that
+// make it up, and the composite-key -> primary-key map built from them.
+type structIndex struct {
+	fields []string
+	lookup map[string]string
+}
+
+// indexName is how a declared []string key is named for StructCache.Get:
+// its field names joined with a comma, e.g. []string{"Username", "Domain"}
+// is named "Username,Domain".
+func indexName(fields []string) string {
+	return strings.Join(fields, ",")
+}
+
+// StructCache is a lookup cache for values of a single struct type,
+// indexed by one or more declared sets of fields (e.g. {"ID"} or
+// {"Username", "Domain"}), inspired by gruf/go-cache's result cache.
+// Unlike Cache, entries aren't addressed by an arbitrary string key:
+// callers look them up by naming one of the declared key sets and
+// supplying its field values.
+//
+// The first key set passed to NewStructCache doubles as the cache's
+// primary key; every other declared key set is a secondary index mapping
+// its own composite key to that primary key. All declared key sets must
+// uniquely identify an entry - Put fails with ErrKeyCollision otherwise.
+type StructCache struct {
+	mutex   sync.Mutex
+	ttl     time.Duration
+	items   map[string]*Item
+	primary string // indexName(the first key passed to NewStructCache)
+	indexes map[string]*structIndex
+	keysOf  map[string]map[string]string // primary key -> {index name: composite key}
+}
+
+// NewStructCache creates a StructCache whose entries expire ttl after
+// they are last looked up. keys declares one or more lookup keys as sets
+// of field names on the struct type this cache will hold, e.g.
+//
+//	NewStructCache(time.Minute, []string{"ID"}, []string{"Username", "Domain"})
+//
+// The first key set is used as the cache's primary key. NewStructCache
+// panics if keys is empty.
+func NewStructCache(ttl time.Duration, keys ...[]string) *StructCache {
+	if len(keys) == 0 {
+		panic("cache: NewStructCache requires at least one key")
+	}
+
+	c := &StructCache{
+		ttl:     ttl,
+		items:   map[string]*Item{},
+		primary: indexName(keys[0]),
+		indexes: make(map[string]*structIndex, len(keys)),
+		keysOf:  map[string]map[string]string{},
+	}
+	for _, fields := range keys {
+		c.indexes[indexName(fields)] = &structIndex{fields: fields, lookup: map[string]string{}}
+	}
+	c.startCleanupTimer()
+	return c
+}
+
+// fieldsOf resolves fields on v (a struct, or a pointer to one) to their
+// values via reflection.
+func fieldsOf(v interface{}, fields []string) ([]interface{}, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cache: StructCache value must be a struct, got %T", v)
+	}
+
+	values := make([]interface{}, len(fields))
+	for i, name := range fields {
+		fv := rv.FieldByName(name)
+		if !fv.IsValid() {
+			return nil, fmt.Errorf("cache: struct %s has no field %q", rv.Type(), name)
+		}
+		values[i] = fv.Interface()
+	}
+	return values, nil
+}
+
+// compositeKey renders fieldValues into a single stable string via
+// fnv-1a, the same fast non-cryptographic hash ShardedCache uses to pick
+// a shard. Callers must pass fieldValues of the same concrete types the
+// struct fields hold, or the rendering won't match.
+func compositeKey(fieldValues []interface{}) string {
+	h := fnv.New64a()
+	for _, v := range fieldValues {
+		fmt.Fprintf(h, "%#v\x00", v)
+	}
+	return fmt.Sprintf("%x", h.Sum64())
+}
+
+// Put inserts v, indexing it under every declared key. If v collides with
+// a different, still-live entry on any declared index, Put fails with
+// ErrKeyCollision and leaves the cache unchanged. Putting a value that
+// resolves to an already-present primary key overwrites that entry,
+// dropping its old index entries first in case an indexed field changed.
+func (c *StructCache) Put(v interface{}) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	keys := make(map[string]string, len(c.indexes))
+	for name, idx := range c.indexes {
+		values, err := fieldsOf(v, idx.fields)
+		if err != nil {
+			return err
+		}
+		keys[name] = compositeKey(values)
+	}
+	primaryKey := keys[c.primary]
+
+	for name, idx := range c.indexes {
+		existing, ok := idx.lookup[keys[name]]
+		if !ok || existing == primaryKey {
+			continue
+		}
+		item, live := c.items[existing]
+		if live && !item.Expired() {
+			return ErrKeyCollision
+		}
+		// existing is stale (expired, or already gone): evict it outright
+		// rather than just skipping past it, or its own keysOf entry for
+		// this index would still point here, and a later cleanup sweep of
+		// existing would delete the mapping we're about to give to v.
+		if live {
+			c.evictLocked(existing)
+		}
+	}
+
+	if _, exists := c.items[primaryKey]; exists {
+		c.evictLocked(primaryKey)
+	}
+
+	c.items[primaryKey] = NewItem(v, c.ttl)
+	c.keysOf[primaryKey] = keys
+	for name, idx := range c.indexes {
+		idx.lookup[keys[name]] = primaryKey
+	}
+	return nil
+}
+
+// Get looks up the entry whose keyName index (the comma-joined field
+// names declared to NewStructCache, e.g. "ID" or "Username,Domain")
+// matches fieldValues. It also touches the item, extending its life, the
+// same way Cache.Get does.
+func (c *StructCache) Get(keyName string, fieldValues ...interface{}) (*Item, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	idx, ok := c.indexes[keyName]
+	if !ok || len(fieldValues) != len(idx.fields) {
+		return nil, false
+	}
+
+	primaryKey, ok := idx.lookup[compositeKey(fieldValues)]
+	if !ok {
+		return nil, false
+	}
+
+	item, exists := c.items[primaryKey]
+	if !exists {
+		return nil, false
+	}
+	if item.Expired() {
+		c.evictLocked(primaryKey)
+		return nil, false
+	}
+	item.Touch()
+	return item, true
+}
+
+// Delete removes the entry looked up via keyName/fieldValues from the
+// primary map and every declared index. It returns whether an entry was
+// found.
+func (c *StructCache) Delete(keyName string, fieldValues ...interface{}) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	idx, ok := c.indexes[keyName]
+	if !ok {
+		return false
+	}
+	primaryKey, ok := idx.lookup[compositeKey(fieldValues)]
+	if !ok {
+		return false
+	}
+	if _, exists := c.items[primaryKey]; !exists {
+		return false
+	}
+	c.evictLocked(primaryKey)
+	return true
+}
+
+// Count returns the number of live entries in the cache.
+func (c *StructCache) Count() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	count := 0
+	for _, item := range c.items {
+		if !item.Expired() {
+			count++
+		}
+	}
+	return count
+}
+
+// Clear removes every entry from the cache and every index.
+func (c *StructCache) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.items = map[string]*Item{}
+	c.keysOf = map[string]map[string]string{}
+	for _, idx := range c.indexes {
+		idx.lookup = map[string]string{}
+	}
+}
+
+// evictLocked removes primaryKey from the primary map and from every
+// index it was registered under. Callers must hold c.mutex.
+func (c *StructCache) evictLocked(primaryKey string) {
+	for name, key := range c.keysOf[primaryKey] {
+		delete(c.indexes[name].lookup, key)
+	}
+	delete(c.keysOf, primaryKey)
+	delete(c.items, primaryKey)
+}
+
+func (c *StructCache) cleanup() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for key, item := range c.items {
+		if item.Expired() {
+			c.evictLocked(key)
+		}
+	}
+}
+
+func (c *StructCache) startCleanupTimer() {
+	duration := c.ttl
+	if duration < time.Second {
+		duration = time.Second
+	}
+	ticker := time.Tick(duration)
+	go (func() {
+		for {
+			select {
+			case <-ticker:
+				c.cleanup()
+			}
+		}
+	})()
+}
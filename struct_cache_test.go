@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+type user struct {
+	ID       int
+	Username string
+	Domain   string
+}
+
+func newUserCache() *StructCache {
+	return NewStructCache(time.Minute, []string{"ID"}, []string{"Username", "Domain"})
+}
+
+// TestStructCachePutGetByEachIndex checks a value can be looked up by every
+// declared key set, not just the primary one.
+func TestStructCachePutGetByEachIndex(t *testing.T) {
+	c := newUserCache()
+
+	if err := c.Put(user{ID: 1, Username: "alice", Domain: "example.com"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	item, found := c.Get("ID", 1)
+	if !found {
+		t.Fatal("expected to find by ID")
+	}
+	got := item.Data().(user)
+	if got.Username != "alice" {
+		t.Fatalf("got %+v, want Username alice", got)
+	}
+
+	item, found = c.Get("Username,Domain", "alice", "example.com")
+	if !found {
+		t.Fatal("expected to find by Username,Domain")
+	}
+	if got := item.Data().(user); got.ID != 1 {
+		t.Fatalf("got %+v, want ID 1", got)
+	}
+}
+
+// TestStructCachePutCollision checks Put refuses to insert a value whose
+// secondary-index key collides with a different, still-live entry, and
+// leaves the cache unchanged.
+func TestStructCachePutCollision(t *testing.T) {
+	c := newUserCache()
+
+	if err := c.Put(user{ID: 1, Username: "alice", Domain: "example.com"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	err := c.Put(user{ID: 2, Username: "alice", Domain: "example.com"})
+	if err != ErrKeyCollision {
+		t.Fatalf("got err %v, want ErrKeyCollision", err)
+	}
+
+	if c.Count() != 1 {
+		t.Fatalf("got Count() %d, want 1 (collision must not mutate the cache)", c.Count())
+	}
+	if _, found := c.Get("ID", 2); found {
+		t.Fatal("colliding entry should not have been inserted")
+	}
+}
+
+// TestStructCachePutOverwriteReindexes checks that putting a value under
+// an existing primary key, but with a changed secondary-index field,
+// drops the stale secondary-index entry and the new one resolves.
+func TestStructCachePutOverwriteReindexes(t *testing.T) {
+	c := newUserCache()
+
+	if err := c.Put(user{ID: 1, Username: "alice", Domain: "example.com"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := c.Put(user{ID: 1, Username: "alice2", Domain: "example.com"}); err != nil {
+		t.Fatalf("overwrite Put: %v", err)
+	}
+
+	if _, found := c.Get("Username,Domain", "alice", "example.com"); found {
+		t.Fatal("stale secondary-index entry should have been dropped on overwrite")
+	}
+	item, found := c.Get("Username,Domain", "alice2", "example.com")
+	if !found {
+		t.Fatal("expected to find the overwritten entry by its new secondary key")
+	}
+	if got := item.Data().(user); got.Username != "alice2" {
+		t.Fatalf("got %+v, want Username alice2", got)
+	}
+	if c.Count() != 1 {
+		t.Fatalf("got Count() %d, want 1", c.Count())
+	}
+}
+
+// TestStructCachePutReusesExpiredCollision checks that a secondary-index
+// key held by an expired entry does not block a new Put - only a live
+// collision should.
+func TestStructCachePutReusesExpiredCollision(t *testing.T) {
+	c := NewStructCache(20*time.Millisecond, []string{"ID"}, []string{"Username", "Domain"})
+
+	if err := c.Put(user{ID: 1, Username: "alice", Domain: "example.com"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	if err := c.Put(user{ID: 2, Username: "alice", Domain: "example.com"}); err != nil {
+		t.Fatalf("Put after expiry should reuse the key, got: %v", err)
+	}
+
+	item, found := c.Get("Username,Domain", "alice", "example.com")
+	if !found {
+		t.Fatal("expected to find the new entry by the reused secondary key")
+	}
+	if got := item.Data().(user); got.ID != 2 {
+		t.Fatalf("got %+v, want ID 2", got)
+	}
+
+	// A later cleanup sweep must not still think the stale ID=1 entry owns
+	// this secondary-index key, or it would delete the mapping Put just
+	// gave to ID=2.
+	c.cleanup()
+
+	item, found = c.Get("Username,Domain", "alice", "example.com")
+	if !found {
+		t.Fatal("cleanup must not remove the live entry's secondary-index mapping")
+	}
+	if got := item.Data().(user); got.ID != 2 {
+		t.Fatalf("got %+v, want ID 2", got)
+	}
+	if c.Count() != 1 {
+		t.Fatalf("got Count() %d, want 1", c.Count())
+	}
+}
+
+// TestStructCacheDeleteEvictsFromAllIndexes checks Delete removes the
+// entry from every declared index, not just the one it was looked up
+// through.
+func TestStructCacheDeleteEvictsFromAllIndexes(t *testing.T) {
+	c := newUserCache()
+
+	if err := c.Put(user{ID: 1, Username: "alice", Domain: "example.com"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	if !c.Delete("ID", 1) {
+		t.Fatal("expected Delete to report the entry was found")
+	}
+
+	if _, found := c.Get("ID", 1); found {
+		t.Fatal("entry should be gone from the primary index")
+	}
+	if _, found := c.Get("Username,Domain", "alice", "example.com"); found {
+		t.Fatal("entry should also be gone from the secondary index")
+	}
+	if c.Count() != 0 {
+		t.Fatalf("got Count() %d, want 0", c.Count())
+	}
+
+	// The vacated secondary key must be free for a different entry to use.
+	if err := c.Put(user{ID: 2, Username: "alice", Domain: "example.com"}); err != nil {
+		t.Fatalf("Put after delete: %v", err)
+	}
+}
+
+// TestStructCacheClearRemovesAllIndexes checks Clear empties the primary
+// map and every declared index, not just the primary.
+func TestStructCacheClearRemovesAllIndexes(t *testing.T) {
+	c := newUserCache()
+
+	if err := c.Put(user{ID: 1, Username: "alice", Domain: "example.com"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	c.Clear()
+
+	if c.Count() != 0 {
+		t.Fatalf("got Count() %d, want 0", c.Count())
+	}
+	if _, found := c.Get("Username,Domain", "alice", "example.com"); found {
+		t.Fatal("secondary index should be empty after Clear")
+	}
+
+	if err := c.Put(user{ID: 1, Username: "alice", Domain: "example.com"}); err != nil {
+		t.Fatalf("Put after Clear: %v", err)
+	}
+}